@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/urfave/cli/v2"
+)
+
+var logCommand = &cli.Command{
+	Name:  "log",
+	Usage: "Print the log of a build",
+	Flags: []cli.Flag{
+		idFlag,
+	},
+	Action: func(c *cli.Context) error {
+		server, err := openServer(c)
+		if err != nil {
+			return err
+		}
+
+		buildLog, err := server.GetBuildLog(c.Context, c.String("id"))
+		if err != nil {
+			log.Println(err.Error())
+			return err
+		}
+		defer buildLog.Close()
+
+		if _, err := io.Copy(os.Stdout, buildLog); err != nil {
+			log.Println(err.Error())
+			return err
+		}
+		return nil
+	},
+}
+
+var artifactsCommand = &cli.Command{
+	Name:  "artifacts",
+	Usage: "List the artifacts of a build",
+	Flags: []cli.Flag{
+		idFlag,
+	},
+	Action: func(c *cli.Context) error {
+		server, err := openServer(c)
+		if err != nil {
+			return err
+		}
+
+		artifacts, err := server.ListArtifacts(c.Context, c.String("id"))
+		if err != nil {
+			log.Println(err.Error())
+			return err
+		}
+
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Path", "Size"})
+		for _, artifact := range artifacts {
+			t.AppendRow([]interface{}{artifact.Path, artifact.Size})
+		}
+		t.Render()
+		return nil
+	},
+}
+
+var fetchArtifactCommand = &cli.Command{
+	Name:  "fetch-artifact",
+	Usage: "Download an artifact of a build",
+	Flags: []cli.Flag{
+		idFlag,
+		&cli.StringFlag{
+			Name:     "path",
+			Usage:    "Provide artifact path as listed by the artifacts command",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Write the artifact to this file instead of stdout",
+		},
+	},
+	Action: fetchArtifact,
+}
+
+func fetchArtifact(c *cli.Context) error {
+	server, err := openServer(c)
+	if err != nil {
+		return err
+	}
+
+	content, err := server.GetArtifact(c.Context, c.String("id"), c.String("path"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+	defer content.Close()
+
+	var out io.Writer = os.Stdout
+	if output := c.String("output"); output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			log.Println(err.Error())
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if _, err := io.Copy(out, content); err != nil {
+		log.Println(err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+	"github.com/urfave/cli/v2"
+
+	// Backends register themselves with the buildserver package
+	_ "github.com/raghuP9/buildserver-client/pkg/buildserver/githubactions"
+	_ "github.com/raghuP9/buildserver-client/pkg/buildserver/gitlab"
+	_ "github.com/raghuP9/buildserver-client/pkg/buildserver/jenkins"
+	_ "github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+)
+
+var t = table.NewWriter()
+
+// openServer opens the build server from the global flags
+func openServer(c *cli.Context) (buildserver.BuildServer, error) {
+	if c.String("server") == "" {
+		err := errors.New("Build server URL must be provided with --server or BUILDSERVER_URL")
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	server, err := buildserver.Open(c.Context, c.String("server"), buildserver.Credentials{
+		User:  c.String("user"),
+		Token: c.String("token"),
+	})
+	if err != nil {
+		log.Println(err.Error())
+	}
+	return server, err
+}
+
+// renderBuilds prints builds as JSON or as a table
+func renderBuilds(c *cli.Context, builds []buildserver.Build) {
+	switch c.String("format") {
+	case "table":
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"ID", "Pipeline", "Number", "Branch", "State", "Status", "Status Text", "WebURL"})
+		for _, build := range builds {
+			t.AppendRow([]interface{}{
+				build.ID,
+				build.Pipeline,
+				build.Number,
+				build.Branch,
+				build.State,
+				build.Status,
+				build.StatusText,
+				build.WebURL,
+			})
+		}
+		t.Render()
+	default:
+		jsonRender, _ := json.MarshalIndent(builds, "", "  ")
+		log.Println(string(jsonRender))
+	}
+}
+
+// renderBuild prints a build as JSON or as a table
+func renderBuild(c *cli.Context, build buildserver.Build) {
+	switch c.String("format") {
+	case "table":
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"key", "value"})
+		t.AppendRows([]table.Row{
+			{"ID", build.ID},
+			{"Pipeline", build.Pipeline},
+			{"Number", build.Number},
+			{"Branch", build.Branch},
+			{"Revision", build.Revision},
+			{"State", build.State},
+			{"Status", build.Status},
+			{"Status Text", build.StatusText},
+			{"WebURL", build.WebURL},
+		})
+		t.Render()
+	default:
+		jsonRender, _ := json.MarshalIndent(build, "", "  ")
+		log.Println(string(jsonRender))
+	}
+}
+
+var formatFlag = &cli.StringFlag{
+	Name:        "format",
+	Usage:       "Output format, json or table",
+	DefaultText: "json",
+}
+
+var idFlag = &cli.StringFlag{
+	Name:     "id",
+	Usage:    "Provide build ID as returned by start or list",
+	Required: true,
+}
+
+func main() {
+	app := &cli.App{
+		Name:    "buildctl",
+		Usage:   "Trigger and track builds on any supported build server",
+		Version: "1.0.0",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "server",
+				Usage:   "Provide build server URL as <backend>+<scheme>://<host>, e.g. teamcity+https://teamcity.example.com",
+				EnvVars: []string{"BUILDSERVER_URL"},
+			},
+			&cli.StringFlag{
+				Name:    "user",
+				Usage:   "Provide user name for build servers that need one, e.g. jenkins",
+				EnvVars: []string{"BUILDSERVER_USER"},
+			},
+			&cli.StringFlag{
+				Name:    "token",
+				Usage:   "Provide auth token to talk to the build server",
+				EnvVars: []string{"BUILDSERVER_TOKEN"},
+			},
+		},
+		Commands: []*cli.Command{
+			backendsCommand,
+			startCommand,
+			getCommand,
+			listCommand,
+			waitCommand,
+			cancelCommand,
+			stopCommand,
+			logCommand,
+			artifactsCommand,
+			fetchArtifactCommand,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err.Error())
+	}
+}
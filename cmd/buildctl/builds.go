@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/internal/cliutil"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+	"github.com/urfave/cli/v2"
+)
+
+var backendsCommand = &cli.Command{
+	Name:  "backends",
+	Usage: "List the build server backends this binary supports",
+	Action: func(c *cli.Context) error {
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"#", "Backend"})
+		for i, backend := range buildserver.Backends() {
+			t.AppendRow([]interface{}{i + 1, backend})
+		}
+		t.Render()
+		return nil
+	},
+}
+
+var startCommand = &cli.Command{
+	Name:  "start",
+	Usage: "Start a build of a pipeline",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "pipeline",
+			Usage:    "Provide pipeline, e.g. a teamcity build config ID, a jenkins job or a gitlab project",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "Provide branch name to perform build upon",
+		},
+		&cli.StringFlag{
+			Name:  "revision",
+			Usage: "Provide VCS revision (commit SHA) to perform build upon",
+		},
+		&cli.StringSliceFlag{
+			Name:  "param",
+			Usage: "Provide multiple params as key=value, e.g. --param key1=value1 --param key2=value2",
+		},
+		&cli.StringFlag{
+			Name:  "comment",
+			Usage: "Provide text comment",
+			Value: "Build started by buildctl CLI",
+		},
+		&cli.BoolFlag{
+			Name:  "wait",
+			Usage: "Wait for the build to finish and fail when it does not succeed",
+		},
+		&cli.DurationFlag{
+			Name:  "poll-interval",
+			Usage: "Interval at which the build is polled with --wait",
+			Value: buildserver.DefaultPollInterval,
+		},
+		formatFlag,
+	},
+	Action: start,
+}
+
+func start(c *cli.Context) error {
+	server, err := openServer(c)
+	if err != nil {
+		return err
+	}
+
+	params, err := cliutil.ParseParams(c.StringSlice("param"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	build, err := server.StartBuild(c.Context, buildserver.TriggerRequest{
+		Pipeline: c.String("pipeline"),
+		Branch:   c.String("branch"),
+		Revision: c.String("revision"),
+		Comment:  c.String("comment"),
+		Params:   params,
+	})
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+	log.Printf("Started build with ID: %s\n", build.ID)
+
+	if !c.Bool("wait") {
+		renderBuild(c, build)
+		return nil
+	}
+	return wait(c, server, build.ID)
+}
+
+var getCommand = &cli.Command{
+	Name:  "get",
+	Usage: "Get build details by id",
+	Flags: []cli.Flag{
+		idFlag,
+		formatFlag,
+	},
+	Action: func(c *cli.Context) error {
+		server, err := openServer(c)
+		if err != nil {
+			return err
+		}
+
+		build, err := server.GetBuild(c.Context, c.String("id"))
+		if err != nil {
+			log.Println(err.Error())
+			return err
+		}
+		renderBuild(c, build)
+		return nil
+	},
+}
+
+var listCommand = &cli.Command{
+	Name:  "list",
+	Usage: "List builds of a pipeline, newest first",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "pipeline",
+			Usage: "List builds of this pipeline",
+		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "List builds of this branch",
+		},
+		&cli.StringFlag{
+			Name:  "triggered-by",
+			Usage: "List builds triggered by this user",
+		},
+		&cli.StringFlag{
+			Name:  "state",
+			Usage: "List builds in this state: queued, running or finished",
+		},
+		&cli.StringFlag{
+			Name:  "status",
+			Usage: "List builds with this status: success, failure or cancelled",
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "List builds since a duration before now such as 12h, 7d or 2w, or since a date 2006-01-02",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "Maximum number of builds to list",
+			Value: 20,
+		},
+		formatFlag,
+	},
+	Action: list,
+}
+
+func list(c *cli.Context) error {
+	server, err := openServer(c)
+	if err != nil {
+		return err
+	}
+
+	query := buildserver.Query{
+		Pipeline: c.String("pipeline"),
+		Branch:   c.String("branch"),
+		User:     c.String("triggered-by"),
+		State:    buildserver.BuildState(c.String("state")),
+		Status:   buildserver.BuildStatus(c.String("status")),
+		Limit:    c.Int("limit"),
+	}
+	if since := c.String("since"); since != "" {
+		if query.Since, err = cliutil.ParseSince(since, time.Now()); err != nil {
+			log.Println(err.Error())
+			return err
+		}
+	}
+
+	builds, err := server.ListBuilds(c.Context, query)
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+	renderBuilds(c, builds)
+	return nil
+}
+
+var waitCommand = &cli.Command{
+	Name:  "wait",
+	Usage: "Wait for a build to finish and fail when it does not succeed",
+	Flags: []cli.Flag{
+		idFlag,
+		&cli.DurationFlag{
+			Name:  "poll-interval",
+			Usage: "Interval at which the build is polled",
+			Value: buildserver.DefaultPollInterval,
+		},
+		formatFlag,
+	},
+	Action: func(c *cli.Context) error {
+		server, err := openServer(c)
+		if err != nil {
+			return err
+		}
+		return wait(c, server, c.String("id"))
+	},
+}
+
+// wait waits for a build, prints it and returns an
+// error when it finished without succeeding
+func wait(c *cli.Context, server buildserver.BuildServer, id string) error {
+	log.Printf("Waiting for build %s to finish\n", id)
+	build, err := server.WaitForBuild(c.Context, id, c.Duration("poll-interval"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+	renderBuild(c, build)
+
+	if build.Status != buildserver.StatusSuccess {
+		return fmt.Errorf("build %s finished with status %s", build.ID, build.Status)
+	}
+	return nil
+}
+
+var cancelCommand = &cli.Command{
+	Name:  "cancel",
+	Usage: "Cancel a queued build that is not yet running",
+	Flags: []cli.Flag{
+		idFlag,
+		&cli.StringFlag{
+			Name:  "comment",
+			Usage: "Provide text comment",
+			Value: "Build cancelled by buildctl CLI",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		server, err := openServer(c)
+		if err != nil {
+			return err
+		}
+
+		id := c.String("id")
+		if err := server.CancelQueuedBuild(c.Context, id, c.String("comment")); err != nil {
+			log.Println(err.Error())
+			return err
+		}
+		log.Printf("Successfully cancelled queued build with id: %s\n", id)
+		return nil
+	},
+}
+
+var stopCommand = &cli.Command{
+	Name:  "stop",
+	Usage: "Stop a running build",
+	Flags: []cli.Flag{
+		idFlag,
+		&cli.StringFlag{
+			Name:  "comment",
+			Usage: "Provide text comment",
+			Value: "Build stopped by buildctl CLI",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		server, err := openServer(c)
+		if err != nil {
+			return err
+		}
+
+		id := c.String("id")
+		if err := server.StopBuild(c.Context, id, c.String("comment")); err != nil {
+			log.Println(err.Error())
+			return err
+		}
+		log.Printf("Successfully stopped build with id: %s\n", id)
+		return nil
+	},
+}
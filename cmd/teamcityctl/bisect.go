@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/internal/cliutil"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity/bisect"
+	"github.com/urfave/cli/v2"
+)
+
+var bisectCommand = &cli.Command{
+	Name:  "bisect",
+	Usage: "Find the change that broke a pipeline between a good and a bad build",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "pipeline",
+			Usage: "Provide build pipeline ID, pipeline of the bad build when not provided",
+		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "Provide branch name, branch of the bad build when not provided",
+		},
+		&cli.IntFlag{
+			Name:     "good",
+			Usage:    "Provide build ID of the last known good build",
+			Required: true,
+		},
+		&cli.IntFlag{
+			Name:     "bad",
+			Usage:    "Provide build ID of a bad build",
+			Required: true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "param",
+			Usage: "Provide multiple params added to every bisection build as key=value",
+		},
+		&cli.DurationFlag{
+			Name:  "poll-interval",
+			Usage: "Interval at which running builds are polled",
+			Value: 10 * time.Second,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "Provide format to render result. Supported formats: json, table",
+			DefaultText: "table",
+		},
+	},
+	Action: bisectBuilds,
+}
+
+func bisectBuilds(c *cli.Context) error {
+	client := newClient(c, 15*time.Second)
+
+	paramsMap, err := cliutil.ParseParams(c.StringSlice("param"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	result, err := bisect.Run(c.Context, client, c.Int("good"), c.Int("bad"), bisect.Options{
+		BuildTypeID:  c.String("pipeline"),
+		Branch:       c.String("branch"),
+		Params:       paramsMap,
+		PollInterval: c.Duration("poll-interval"),
+		OnStep: func(step bisect.Step) {
+			verdict := "bad"
+			if step.Good {
+				verdict = "good"
+			}
+			log.Printf("Change %s is %s (build %d)\n", step.Change.Version, verdict, step.Build.ID)
+		},
+	})
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	switch c.String("format") {
+	case "json":
+		jsonRender, _ := json.MarshalIndent(result, "", "  ")
+		log.Println(string(jsonRender))
+	default:
+		change := result.FirstBadChange
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"key", "value"})
+		t.AppendRows([]table.Row{
+			{"Candidates", result.Candidates},
+			{"Builds", len(result.Steps)},
+			{"First Bad Commit", change.Version},
+			{"Author", changeAuthor(change)},
+			{"Date", change.Date},
+			{"Comment", strings.TrimSpace(change.Comment)},
+			{"Change", change.WebURL},
+			{"First Bad Build", result.FirstBadBuild.WebURL},
+			{"Last Good Build", result.LastGoodBuild.WebURL},
+		})
+		t.Render()
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+	"github.com/urfave/cli/v2"
+)
+
+var cancelBuildsCommand = &cli.Command{
+	Name:  "cancel-builds",
+	Usage: "Cancel all queued builds matching the query",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "pipeline",
+			Usage: "Cancel builds queued on this build pipeline ID",
+		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "Cancel builds queued on this branch",
+		},
+		&cli.StringFlag{
+			Name:  "user",
+			Usage: "Cancel builds queued by this user",
+		},
+		&cli.StringFlag{
+			Name:  "comment",
+			Usage: "Provide text comment",
+			Value: "Build cancelled by teamcityctl CLI",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Only show the builds that would be cancelled",
+		},
+		&cli.BoolFlag{
+			Name:  "yes",
+			Usage: "Do not ask for confirmation before cancelling",
+		},
+	},
+	Action: cancelBuilds,
+}
+
+func cancelBuilds(c *cli.Context) error {
+	client := newClient(c, 15*time.Second)
+
+	params := teamcity.TCQueueQueryParams{
+		BuildTypeID: c.String("pipeline"),
+		Branch:      c.String("branch"),
+		User:        c.String("user"),
+	}
+	if params.BuildTypeID == "" && params.Branch == "" && params.User == "" {
+		err := errors.New("At least one of --pipeline, --branch or --user must be provided")
+		log.Println(err.Error())
+		return err
+	}
+
+	builds, err := client.ListQueuedBuilds(c.Context, params)
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	if len(builds) == 0 {
+		log.Println("No queued builds match the query")
+		return nil
+	}
+
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Position", "Id", "Pipeline", "Branch", "Queued"})
+	for _, build := range builds {
+		t.AppendRow([]interface{}{
+			build.QueuePosition,
+			build.ID,
+			build.BuildTypeID,
+			build.BranchName,
+			build.QueuedDate,
+		})
+	}
+	t.Render()
+
+	if c.Bool("dry-run") {
+		log.Printf("Dry run: %d queued builds would be cancelled\n", len(builds))
+		return nil
+	}
+
+	if !c.Bool("yes") {
+		fmt.Printf("Cancel %d queued builds? [y/N]: ", len(builds))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+		default:
+			log.Println("Aborted")
+			return nil
+		}
+	}
+
+	ids := make([]int, 0, len(builds))
+	for _, build := range builds {
+		ids = append(ids, build.ID)
+	}
+
+	failed := 0
+	for _, result := range client.CancelQueuedBuilds(c.Context, ids, c.String("comment")) {
+		if result.Err != nil {
+			failed++
+			log.Printf("Failed to cancel queued build with id: %d: %s\n", result.ID, result.Err.Error())
+		}
+	}
+
+	log.Printf("Cancelled %d of %d queued builds\n", len(ids)-failed, len(ids))
+	if failed > 0 {
+		return fmt.Errorf("Failed to cancel %d queued builds", failed)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+	"github.com/urfave/cli/v2"
+)
+
+var chainCommand = &cli.Command{
+	Name:  "chain",
+	Usage: "Show the snapshot dependency chain of a build",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:     "id",
+			Usage:    "Provide unique build ID whose chain is required",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "Provide format to render result. Supported formats: json, tree, dot",
+			DefaultText: "json",
+		},
+	},
+	Action: buildChain,
+}
+
+func buildChain(c *cli.Context) error {
+	client := newClient(c, 15*time.Second)
+
+	chain, err := client.GetBuildChain(c.Context, c.Int("id"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	switch c.String("format") {
+	case "dot":
+		return renderChainDOT(os.Stdout, chain)
+	case "tree":
+		return renderChainTree(os.Stdout, chain)
+	default:
+		jsonRender, _ := json.MarshalIndent(chain, "", "  ")
+		log.Println(string(jsonRender))
+	}
+	return nil
+}
+
+// renderChainDOT writes the chain as a graphviz digraph with
+// failed builds highlighted
+func renderChainDOT(w io.Writer, chain teamcity.TCBuildChain) error {
+	var b strings.Builder
+	b.WriteString("digraph chain {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, build := range chain.Builds {
+		color := "black"
+		switch build.Status {
+		case "SUCCESS":
+			color = "green"
+		case "FAILURE":
+			color = "red"
+		}
+		penwidth := 1
+		if build.ID == chain.Root {
+			penwidth = 3
+		}
+		fmt.Fprintf(&b, "  %d [label=%q, color=%s, penwidth=%d];\n", build.ID, chainNodeLabel(build), color, penwidth)
+	}
+	for _, edge := range chain.Edges {
+		fmt.Fprintf(&b, "  %d -> %d;\n", edge.From, edge.To)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// renderChainTree writes the chain as an indented tree starting from the
+// builds nothing depends upon, with the dependencies of a build below it
+func renderChainTree(w io.Writer, chain teamcity.TCBuildChain) error {
+	var b strings.Builder
+	printed := map[int]bool{}
+
+	var walk func(id int, depth int)
+	walk = func(id int, depth int) {
+		build, _ := chain.Build(id)
+		marker := ""
+		if id == chain.Root {
+			marker = " <-"
+		}
+		if printed[id] {
+			fmt.Fprintf(&b, "%s%s (see above)\n", strings.Repeat("  ", depth), chainNodeLabel(build))
+			return
+		}
+		printed[id] = true
+		fmt.Fprintf(&b, "%s%s [%s]%s\n", strings.Repeat("  ", depth), chainNodeLabel(build), chainNodeState(build), marker)
+		for _, dependency := range chain.Dependencies(id) {
+			walk(dependency, depth+1)
+		}
+	}
+
+	for _, build := range chain.Builds {
+		if len(chain.Dependents(build.ID)) == 0 {
+			walk(build.ID, 0)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func chainNodeLabel(build teamcity.TCBuildDetails) string {
+	return fmt.Sprintf("%s #%s (%d)", build.BuildTypeID, build.Number, build.ID)
+}
+
+func chainNodeState(build teamcity.TCBuildDetails) string {
+	if build.State != "finished" {
+		return build.State
+	}
+	if build.StatusText != "" {
+		return fmt.Sprintf("%s: %s", build.Status, build.StatusText)
+	}
+	return build.Status
+}
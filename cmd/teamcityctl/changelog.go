@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+	"github.com/urfave/cli/v2"
+)
+
+var changelogCommand = &cli.Command{
+	Name:  "changelog",
+	Usage: "List changes between two builds of a pipeline",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:     "from",
+			Usage:    "Provide build ID of the previous release, its changes are excluded",
+			Required: true,
+		},
+		&cli.IntFlag{
+			Name:     "to",
+			Usage:    "Provide build ID of the current release candidate, its changes are included",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "Provide format to render result. Supported formats: json, markdown",
+			DefaultText: "json",
+		},
+	},
+	Action: changelog,
+}
+
+func changelog(c *cli.Context) error {
+	client := newClient(c, 15*time.Second)
+
+	from, to := c.Int("from"), c.Int("to")
+	changes, err := client.ChangesBetween(c.Context, from, to)
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	switch c.String("format") {
+	case "markdown", "md":
+		return renderChangelogMarkdown(os.Stdout, from, to, changes)
+	default:
+		jsonRender, _ := json.MarshalIndent(changes, "", "  ")
+		log.Println(string(jsonRender))
+	}
+	return nil
+}
+
+// renderChangelogMarkdown writes changes as a markdown list with
+// one entry per commit
+func renderChangelogMarkdown(w io.Writer, from, to int, changes []teamcity.TCChange) error {
+	if _, err := fmt.Fprintf(w, "## Changes from build %d to build %d\n\n", from, to); err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "No changes")
+		return err
+	}
+
+	for _, change := range changes {
+		version := change.Version
+		if len(version) > 12 {
+			version = version[:12]
+		}
+		if change.WebURL != "" {
+			version = fmt.Sprintf("[%s](%s)", version, change.WebURL)
+		}
+
+		summary := strings.TrimSpace(strings.SplitN(strings.TrimSpace(change.Comment), "\n", 2)[0])
+		if _, err := fmt.Fprintf(w, "- %s %s (%s)\n", version, summary, changeAuthor(change)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+	"github.com/urfave/cli/v2"
+)
+
+var changesCommand = &cli.Command{
+	Name:  "changes",
+	Usage: "List VCS changes included in a build",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:     "id",
+			Usage:    "Provide unique build ID whose changes are required",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "files",
+			Usage: "Show files modified by each change in table format",
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "Provide format to render result. Supported formats: json, table",
+			DefaultText: "json",
+		},
+	},
+	Action: listChanges,
+}
+
+func listChanges(c *cli.Context) error {
+	client := newClient(c, 15*time.Second)
+
+	changes, err := client.ListChanges(c.Context, c.Int("id"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	switch c.String("format") {
+	case "table":
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Id", "Version", "User", "Date", "Comment"})
+		for _, change := range changes {
+			t.AppendRow([]interface{}{
+				change.ID,
+				change.Version,
+				changeAuthor(change),
+				change.Date,
+				strings.TrimSpace(change.Comment),
+			})
+			if c.Bool("files") && change.Files != nil {
+				for _, file := range change.Files.File {
+					t.AppendRow([]interface{}{"", file.ChangeType, file.File})
+				}
+			}
+		}
+		t.Render()
+	default:
+		jsonRender, _ := json.MarshalIndent(changes, "", "  ")
+		log.Println(string(jsonRender))
+	}
+	return nil
+}
+
+// changeAuthor returns the teamcity username of the committer
+// falling back to the VCS username
+func changeAuthor(change teamcity.TCChange) string {
+	if change.User != nil && change.User.Username != "" {
+		return change.User.Username
+	}
+	return change.Username
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity/flaky"
+	"github.com/urfave/cli/v2"
+)
+
+var flakyCommand = &cli.Command{
+	Name:  "flaky",
+	Usage: "Detect flaky tests across the build history of a pipeline",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "pipeline",
+			Usage:    "Provide build pipeline ID whose builds are scanned",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "Provide branch name whose builds are scanned, default branch when not provided",
+		},
+		&cli.UintFlag{
+			Name:  "builds",
+			Usage: "Number of most recent builds to scan",
+			Value: 100,
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "Number of builds whose test results are fetched in parallel",
+			Value: 8,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "Provide format to render result. Supported formats: json, table",
+			DefaultText: "json",
+		},
+	},
+	Action: detectFlaky,
+}
+
+func detectFlaky(c *cli.Context) error {
+	client := newClient(c, 30*time.Second)
+
+	reports, err := flaky.Detect(c.Context, client, flaky.Options{
+		BuildTypeID: c.String("pipeline"),
+		Branch:      c.String("branch"),
+		Builds:      c.Uint("builds"),
+		Concurrency: c.Int("concurrency"),
+	})
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	switch c.String("format") {
+	case "table":
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"#", "Test", "Score", "Runs", "Failures", "Flips", "Unexplained Flips", "Mixed Revisions", "Last Failed Build"})
+		for i, report := range reports {
+			t.AppendRow([]interface{}{
+				i + 1,
+				report.Name,
+				fmt.Sprintf("%.2f", report.Score),
+				report.Runs,
+				report.Failures,
+				report.Flips,
+				report.UnexplainedFlips,
+				report.MixedRevisions,
+				report.LastFailedBuildID,
+			})
+		}
+		t.Render()
+	default:
+		jsonRender, _ := json.MarshalIndent(reports, "", "  ")
+		log.Println(string(jsonRender))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity/orchestrate"
+	"github.com/urfave/cli/v2"
+)
+
+var runFlowCommand = &cli.Command{
+	Name:      "run-flow",
+	Usage:     "Run a graph of pipelines declared in a YAML or JSON file",
+	ArgsUsage: "flow.yaml",
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "poll-interval",
+			Usage: "Interval at which running builds are polled",
+			Value: 10 * time.Second,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "Provide format to render result. Supported formats: json, table",
+			DefaultText: "json",
+		},
+	},
+	Action: runFlow,
+}
+
+func runFlow(c *cli.Context) error {
+	if c.NArg() != 1 {
+		err := errors.New("Provide path of exactly one flow file")
+		log.Println(err.Error())
+		return err
+	}
+
+	flow, err := orchestrate.Load(c.Args().First())
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	client := newClient(c, 15*time.Second)
+	results, err := orchestrate.Run(c.Context, client, flow, orchestrate.Options{
+		PollInterval: c.Duration("poll-interval"),
+		OnEvent: func(step, state string, id int) {
+			log.Printf("Step %s %s (build %d)\n", step, state, id)
+		},
+	})
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.State != orchestrate.StateSucceeded {
+			failed++
+		}
+	}
+
+	switch c.String("format") {
+	case "table":
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Step", "State", "Build ID", "Error"})
+		for _, result := range results {
+			t.AppendRow([]interface{}{result.Step, result.State, result.BuildID, result.Error})
+		}
+		t.Render()
+	default:
+		jsonRender, _ := json.MarshalIndent(results, "", "  ")
+		log.Println(string(jsonRender))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d steps of flow %s did not succeed", failed, len(results), flow.Name)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/internal/cliutil"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+	"github.com/urfave/cli/v2"
+)
+
+var startMatrixCommand = &cli.Command{
+	Name:  "start-matrix",
+	Usage: "Start one build per combination of param values",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "pipeline",
+			Usage:    "Provide build pipeline ID",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "branch",
+			Usage:    "Provide branch name to perform builds upon",
+			Required: true,
+		},
+		&cli.StringSliceFlag{
+			Name:     "axis",
+			Usage:    "Provide multiple axes as param=value1,value2, e.g. --axis env.OS=linux,windows --axis env.JDK=11,17",
+			Required: true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "param",
+			Usage: "Provide multiple params common to all builds as key=value, e.g. --param key1=value1",
+		},
+		&cli.StringFlag{
+			Name:  "comment",
+			Usage: "Provide text comment",
+			Value: "Matrix build started by teamcityctl CLI",
+		},
+		&cli.BoolFlag{
+			Name:  "wait",
+			Usage: "Wait for all builds to finish",
+		},
+		&cli.DurationFlag{
+			Name:  "poll-interval",
+			Usage: "Interval at which running builds are polled",
+			Value: 10 * time.Second,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "Provide format to render result. Supported formats: json, table",
+			DefaultText: "table",
+		},
+	},
+	Action: startMatrix,
+}
+
+func startMatrix(c *cli.Context) error {
+	client := newClient(c, 15*time.Second)
+
+	axes, err := parseAxes(c.StringSlice("axis"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	paramsMap, err := cliutil.ParseParams(c.StringSlice("param"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	runs := client.StartMatrix(c.Context, teamcity.StartBuildOptions{
+		BuildTypeID: c.String("pipeline"),
+		Branch:      c.String("branch"),
+		Comment:     c.String("comment"),
+		Params:      paramsMap,
+	}, axes)
+	log.Printf("Started %d matrix builds\n", len(runs))
+
+	if c.Bool("wait") {
+		runs = client.WaitForMatrix(c.Context, runs, c.Duration("poll-interval"))
+	}
+
+	switch c.String("format") {
+	case "json":
+		jsonRender, _ := json.MarshalIndent(runs, "", "  ")
+		log.Println(string(jsonRender))
+	default:
+		renderMatrix(axes, runs)
+	}
+
+	for _, run := range runs {
+		if run.Error != "" || (run.Build != nil && run.Build.Status != "SUCCESS") {
+			return errors.New("Not all matrix builds succeeded")
+		}
+	}
+	return nil
+}
+
+// parseAxes parses axes provided in the form of NAME=VALUE1,VALUE2
+func parseAxes(values []string) ([]teamcity.TCMatrixAxis, error) {
+	axes := []teamcity.TCMatrixAxis{}
+	for _, v := range values {
+		axis := strings.SplitN(v, "=", 2)
+		if len(axis) != 2 || axis[1] == "" {
+			return nil, errors.New("Axis not provided in the form of NAME=VALUE1,VALUE2")
+		}
+		axes = append(axes, teamcity.TCMatrixAxis{Name: axis[0], Values: strings.Split(axis[1], ",")})
+	}
+	return axes, nil
+}
+
+// renderMatrix renders the runs as a grid when there are two axes
+// and as a list of combinations otherwise
+func renderMatrix(axes []teamcity.TCMatrixAxis, runs []teamcity.TCMatrixRun) {
+	t.SetOutputMirror(os.Stdout)
+
+	if len(axes) == 2 {
+		header := table.Row{fmt.Sprintf("%s \\ %s", axes[0].Name, axes[1].Name)}
+		for _, value := range axes[1].Values {
+			header = append(header, value)
+		}
+		t.AppendHeader(header)
+
+		// Runs are ordered with the values of the last axis changing fastest
+		for i, value := range axes[0].Values {
+			row := table.Row{value}
+			for j := range axes[1].Values {
+				row = append(row, matrixCell(runs[i*len(axes[1].Values)+j]))
+			}
+			t.AppendRow(row)
+		}
+		t.Render()
+		return
+	}
+
+	header := table.Row{}
+	for _, axis := range axes {
+		header = append(header, axis.Name)
+	}
+	t.AppendHeader(append(header, "Result"))
+	for _, run := range runs {
+		row := table.Row{}
+		for _, axis := range axes {
+			row = append(row, run.Values[axis.Name])
+		}
+		t.AppendRow(append(row, matrixCell(run)))
+	}
+	t.Render()
+}
+
+func matrixCell(run teamcity.TCMatrixRun) string {
+	switch {
+	case run.Error != "":
+		return fmt.Sprintf("error: %s", run.Error)
+	case run.Build != nil:
+		return fmt.Sprintf("%d %s", run.BuildID, run.Build.Status)
+	default:
+		return fmt.Sprintf("%d queued", run.BuildID)
+	}
+}
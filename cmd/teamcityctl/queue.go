@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+	"github.com/urfave/cli/v2"
+)
+
+var queueCommand = &cli.Command{
+	Name:  "queue",
+	Usage: "Inspect and reorder the teamcity build queue",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "list queued builds as per query",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "pipeline",
+					Usage: "Provide build pipeline ID to list queued builds of",
+				},
+				&cli.StringFlag{
+					Name:  "project",
+					Usage: "Provide project ID to list queued builds of",
+				},
+				&cli.StringFlag{
+					Name:  "branch",
+					Usage: "Provide branch name for listing builds queued on this branch",
+				},
+				&cli.StringFlag{
+					Name:  "user",
+					Usage: "Provide username for listing builds queued by this user",
+				},
+				&cli.UintFlag{
+					Name:  "count",
+					Usage: "Maximum number of queued builds to show",
+				},
+				&cli.StringFlag{
+					Name:        "format",
+					Usage:       "Provide format to render result. Supported formats: json, table",
+					DefaultText: "json",
+				},
+			},
+			Action: listQueue,
+		},
+		{
+			Name:  "show",
+			Usage: "show why a queued build is waiting",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:     "id",
+					Usage:    "Provide unique build ID of the queued build",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:        "format",
+					Usage:       "Provide format to render result. Supported formats: json, table",
+					DefaultText: "json",
+				},
+			},
+			Action: showQueuedBuild,
+		},
+		{
+			Name:  "top",
+			Usage: "move a queued build to the top of the queue",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:     "id",
+					Usage:    "Provide unique build ID of the queued build",
+					Required: true,
+				},
+			},
+			Action: moveQueuedBuildToTop,
+		},
+	},
+}
+
+func listQueue(c *cli.Context) error {
+	client := newClient(c, 15*time.Second)
+
+	builds, err := client.ListQueuedBuilds(c.Context, teamcity.TCQueueQueryParams{
+		BuildTypeID: c.String("pipeline"),
+		ProjectID:   c.String("project"),
+		Branch:      c.String("branch"),
+		User:        c.String("user"),
+		Count:       c.Uint("count"),
+	})
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	switch c.String("format") {
+	case "table":
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Position", "Id", "Pipeline", "Branch", "Queued", "Wait Reason"})
+		for _, build := range builds {
+			t.AppendRow([]interface{}{
+				build.QueuePosition,
+				build.ID,
+				build.BuildTypeID,
+				build.BranchName,
+				build.QueuedDate,
+				build.WaitReason,
+			})
+		}
+		t.Render()
+	default:
+		jsonRender, _ := json.MarshalIndent(builds, "", "  ")
+		log.Println(string(jsonRender))
+	}
+	return nil
+}
+
+func showQueuedBuild(c *cli.Context) error {
+	client := newClient(c, 15*time.Second)
+
+	build, err := client.GetQueuedBuild(c.Context, c.Int("id"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	switch c.String("format") {
+	case "table":
+		agents := make([]string, 0, len(build.CompatibleAgents))
+		for _, agent := range build.CompatibleAgents {
+			agents = append(agents, agent.Name)
+		}
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"key", "value"})
+		t.AppendRows([]table.Row{
+			{"ID", build.ID},
+			{"Pipeline", build.BuildTypeID},
+			{"Branch", build.BranchName},
+			{"Position", build.QueuePosition},
+			{"Wait Reason", build.WaitReason},
+			{"Queued", build.QueuedDate},
+			{"Estimated Start", build.StartEstimate},
+			{"Compatible Agents", len(agents)},
+			{"Agents", strings.Join(agents, ", ")},
+			{"WebURL", build.WebURL},
+		})
+		t.Render()
+	default:
+		jsonRender, _ := json.MarshalIndent(build, "", "  ")
+		log.Println(string(jsonRender))
+	}
+	return nil
+}
+
+func moveQueuedBuildToTop(c *cli.Context) error {
+	client := newClient(c, 5*time.Second)
+
+	id := c.Int("id")
+	if err := client.MoveQueuedBuildToTop(c.Context, id); err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	log.Printf("Successfully moved queued build with id: %d to top of the queue\n", id)
+	return nil
+}
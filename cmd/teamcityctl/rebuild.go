@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/internal/cliutil"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+	"github.com/urfave/cli/v2"
+)
+
+var rebuildCommand = &cli.Command{
+	Name:  "rebuild",
+	Usage: "Re-queue an existing build with identical inputs",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:     "id",
+			Usage:    "Provide unique build ID of the build to rebuild",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "Override branch of the original build",
+		},
+		&cli.StringSliceFlag{
+			Name:  "param",
+			Usage: "Override params of the original build as key=value, e.g. --param key1=value1 --param key2=value2",
+		},
+		&cli.BoolFlag{
+			Name:  "same-revision",
+			Usage: "Build on the same VCS revisions as the original build",
+		},
+		&cli.StringFlag{
+			Name:  "comment",
+			Usage: "Provide text comment",
+		},
+	},
+	Action: rebuild,
+}
+
+func rebuild(c *cli.Context) error {
+	client := newClient(c, 15*time.Second)
+
+	paramsMap, err := cliutil.ParseParams(c.StringSlice("param"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	id, err := client.RebuildBuild(c.Context, c.Int("id"), teamcity.TCRebuildOptions{
+		Branch:       c.String("branch"),
+		Comment:      c.String("comment"),
+		Params:       paramsMap,
+		SameRevision: c.Bool("same-revision"),
+	})
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	log.Printf("Started rebuild of build %d with ID: %d\n", c.Int("id"), id)
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "Build ID"})
+	t.AppendRow([]interface{}{1, id})
+	t.Render()
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/internal/cliutil"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity/analytics"
+	"github.com/urfave/cli/v2"
+)
+
+var reportCommand = &cli.Command{
+	Name:  "report",
+	Usage: "Report success rate, queue and run times and failure streaks of pipelines",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "pipeline",
+			Usage:    "Provide multiple build pipeline IDs, e.g. --pipeline pipeline1 --pipeline pipeline2",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "Provide branch name, default branch when not provided",
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "Start of the date window as duration, e.g. 30d, 12h, or date, e.g. 2020-10-01",
+			Value: "30d",
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "Provide format to render result. Supported formats: json, table, csv",
+			DefaultText: "json",
+		},
+	},
+	Action: report,
+}
+
+func report(c *cli.Context) error {
+	client := newClient(c, 30*time.Second)
+
+	since, err := cliutil.ParseSince(c.String("since"), time.Now())
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	reports := []analytics.Report{}
+	for _, pipeline := range c.StringSlice("pipeline") {
+		r, err := analytics.Generate(c.Context, client, analytics.Options{
+			BuildTypeID: pipeline,
+			Branch:      c.String("branch"),
+			Since:       since,
+		})
+		if err != nil {
+			log.Println(err.Error())
+			return err
+		}
+		reports = append(reports, r)
+	}
+
+	switch c.String("format") {
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{
+			"pipeline", "builds", "successes", "failures", "success_rate",
+			"queue_mean_seconds", "queue_p50_seconds", "queue_p95_seconds",
+			"run_mean_seconds", "run_p50_seconds", "run_p95_seconds",
+			"longest_failure_streak", "current_failure_streak",
+		})
+		for _, r := range reports {
+			w.Write([]string{
+				r.BuildTypeID,
+				strconv.Itoa(r.Builds),
+				strconv.Itoa(r.Successes),
+				strconv.Itoa(r.Failures),
+				strconv.FormatFloat(r.SuccessRate, 'f', 4, 64),
+				seconds(r.QueueTime.Mean),
+				seconds(r.QueueTime.P50),
+				seconds(r.QueueTime.P95),
+				seconds(r.RunTime.Mean),
+				seconds(r.RunTime.P50),
+				seconds(r.RunTime.P95),
+				strconv.Itoa(r.LongestFailureStreak),
+				strconv.Itoa(r.CurrentFailureStreak),
+			})
+		}
+		w.Flush()
+		return w.Error()
+	case "table":
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{
+			"Pipeline", "Builds", "Success Rate",
+			"Queue Mean", "Queue P50", "Queue P95",
+			"Run Mean", "Run P50", "Run P95",
+			"Longest Failure Streak", "Current Failure Streak",
+		})
+		for _, r := range reports {
+			t.AppendRow([]interface{}{
+				r.BuildTypeID,
+				r.Builds,
+				fmt.Sprintf("%.1f%%", r.SuccessRate*100),
+				r.QueueTime.Mean.Round(time.Second),
+				r.QueueTime.P50.Round(time.Second),
+				r.QueueTime.P95.Round(time.Second),
+				r.RunTime.Mean.Round(time.Second),
+				r.RunTime.P50.Round(time.Second),
+				r.RunTime.P95.Round(time.Second),
+				r.LongestFailureStreak,
+				r.CurrentFailureStreak,
+			})
+		}
+		t.Render()
+	default:
+		jsonRender, _ := json.MarshalIndent(reports, "", "  ")
+		log.Println(string(jsonRender))
+	}
+	return nil
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 0, 64)
+}
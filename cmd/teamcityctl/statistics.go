@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+	"github.com/urfave/cli/v2"
+)
+
+var statisticsCommand = &cli.Command{
+	Name:  "statistics",
+	Usage: "Get build statistics and custom metrics",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "get",
+			Usage: "get all statistics of a build",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:     "id",
+					Usage:    "Provide unique build ID whose statistics are required",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:        "format",
+					Usage:       "Provide format to render result. Supported formats: json, table, csv",
+					DefaultText: "json",
+				},
+			},
+			Action: getStatistics,
+		},
+		{
+			Name:  "series",
+			Usage: "collect one statistic across the most recent builds of a pipeline",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "pipeline",
+					Usage:    "Provide build pipeline ID",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:  "branch",
+					Usage: "Provide branch name, default branch when not provided",
+				},
+				&cli.StringFlag{
+					Name:     "metric",
+					Usage:    "Provide name of the statistic, e.g. BuildDuration",
+					Required: true,
+				},
+				&cli.UintFlag{
+					Name:  "builds",
+					Usage: "Number of most recent builds to collect the statistic from",
+					Value: 50,
+				},
+				&cli.StringFlag{
+					Name:        "format",
+					Usage:       "Provide format to render result. Supported formats: json, table, csv",
+					DefaultText: "json",
+				},
+			},
+			Action: getStatisticSeries,
+		},
+	},
+}
+
+func getStatistics(c *cli.Context) error {
+	client := newClient(c, 15*time.Second)
+
+	statistics, err := client.GetBuildStatistics(c.Context, c.Int("id"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	names := make([]string, 0, len(statistics))
+	for name := range statistics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	switch c.String("format") {
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"name", "value"})
+		for _, name := range names {
+			w.Write([]string{name, strconv.FormatFloat(statistics[name], 'f', -1, 64)})
+		}
+		w.Flush()
+		return w.Error()
+	case "table":
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Name", "Value"})
+		for _, name := range names {
+			t.AppendRow([]interface{}{name, statistics[name]})
+		}
+		t.Render()
+	default:
+		jsonRender, _ := json.MarshalIndent(statistics, "", "  ")
+		log.Println(string(jsonRender))
+	}
+	return nil
+}
+
+func getStatisticSeries(c *cli.Context) error {
+	client := newClient(c, 15*time.Second)
+
+	points, err := client.GetStatisticSeries(c.Context, teamcity.TCStatisticQueryParams{
+		BuildTypeID: c.String("pipeline"),
+		Branch:      c.String("branch"),
+		Metric:      c.String("metric"),
+		Builds:      c.Uint("builds"),
+	})
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	switch c.String("format") {
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"build_id", "number", "finish_date", c.String("metric")})
+		for _, point := range points {
+			w.Write([]string{
+				strconv.Itoa(point.BuildID),
+				point.Number,
+				point.FinishDate,
+				strconv.FormatFloat(point.Value, 'f', -1, 64),
+			})
+		}
+		w.Flush()
+		return w.Error()
+	case "table":
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Build ID", "Number", "Finished", c.String("metric")})
+		for _, point := range points {
+			t.AppendRow([]interface{}{point.BuildID, point.Number, point.FinishDate, point.Value})
+		}
+		t.Render()
+	default:
+		jsonRender, _ := json.MarshalIndent(points, "", "  ")
+		log.Println(string(jsonRender))
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+var tagFlags = []cli.Flag{
+	&cli.IntFlag{
+		Name:     "id",
+		Usage:    "Provide unique build ID whose tags are changed",
+		Required: true,
+	},
+	&cli.StringSliceFlag{
+		Name:     "tag",
+		Usage:    "Provide multiple tags, e.g. --tag tag1 --tag tag2",
+		Required: true,
+	},
+}
+
+var tagCommand = &cli.Command{
+	Name:  "tag",
+	Usage: "List and change tags of a build",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "list tags of a build",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:     "id",
+					Usage:    "Provide unique build ID whose tags are listed",
+					Required: true,
+				},
+			},
+			Action: listTags,
+		},
+		{
+			Name:   "add",
+			Usage:  "add tags to a build",
+			Flags:  tagFlags,
+			Action: changeTags,
+		},
+		{
+			Name:   "remove",
+			Usage:  "remove tags from a build",
+			Flags:  tagFlags,
+			Action: changeTags,
+		},
+		{
+			Name:   "replace",
+			Usage:  "replace all tags of a build",
+			Flags:  tagFlags,
+			Action: changeTags,
+		},
+	},
+}
+
+var pinCommand = &cli.Command{
+	Name:  "pin",
+	Usage: "Pin a build so that it is not removed by clean-up",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:     "id",
+			Usage:    "Provide unique build ID that needs to be pinned",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "comment",
+			Usage: "Provide text comment",
+			Value: "Build pinned by teamcityctl CLI",
+		},
+		&cli.BoolFlag{
+			Name:  "unpin",
+			Usage: "Unpin the build instead",
+		},
+	},
+	Action: pinBuild,
+}
+
+func listTags(c *cli.Context) error {
+	client := newClient(c, 5*time.Second)
+
+	tags, err := client.GetTags(c.Context, c.Int("id"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	jsonRender, _ := json.MarshalIndent(tags, "", "  ")
+	log.Println(string(jsonRender))
+	return nil
+}
+
+func changeTags(c *cli.Context) error {
+	client := newClient(c, 5*time.Second)
+
+	id := c.Int("id")
+	tags := c.StringSlice("tag")
+
+	var err error
+	switch c.Command.Name {
+	case "add":
+		err = client.AddTags(c.Context, id, tags)
+	case "remove":
+		err = client.RemoveTags(c.Context, id, tags)
+	case "replace":
+		err = client.ReplaceTags(c.Context, id, tags)
+	}
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	log.Printf("Successfully updated tags of build with id: %d\n", id)
+	return nil
+}
+
+func pinBuild(c *cli.Context) error {
+	client := newClient(c, 5*time.Second)
+
+	id := c.Int("id")
+	if c.Bool("unpin") {
+		if err := client.UnpinBuild(c.Context, id, c.String("comment")); err != nil {
+			log.Println(err.Error())
+			return err
+		}
+		log.Printf("Successfully unpinned build with id: %d\n", id)
+		return nil
+	}
+
+	if err := client.PinBuild(c.Context, id, c.String("comment")); err != nil {
+		log.Println(err.Error())
+		return err
+	}
+	log.Printf("Successfully pinned build with id: %d\n", id)
+	return nil
+}
@@ -6,17 +6,31 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/internal/cliutil"
 	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
 	"github.com/urfave/cli/v2"
 )
 
 var t = table.NewWriter()
 
+// newClient creates a teamcity client from the global flags
+func newClient(c *cli.Context, timeout time.Duration) *teamcity.TCClient {
+	return teamcity.NewTeamcityClient(
+		timeout,
+		timeout,
+		timeout,
+		c.String("server"),
+		c.String("token"),
+		c.Bool("secure"),
+	)
+}
+
 func startBuild(c *cli.Context) error {
 	client := teamcity.NewTeamcityClient(
 		5*time.Second,
@@ -26,15 +40,10 @@ func startBuild(c *cli.Context) error {
 		c.String("token"),
 		c.Bool("secure"),
 	)
-	paramsMap := map[string]string{}
-	for _, v := range c.StringSlice("param") {
-		param := strings.Split(v, "=")
-		if len(param) != 2 {
-			err := errors.New("Params not provided in the form of KEY=VALUE")
-			log.Println(err.Error())
-			return err
-		}
-		paramsMap[param[0]] = param[1]
+	paramsMap, err := cliutil.ParseParams(c.StringSlice("param"))
+	if err != nil {
+		log.Println(err.Error())
+		return err
 	}
 
 	snapDependencyMap := map[string]int{}
@@ -59,14 +68,31 @@ func startBuild(c *cli.Context) error {
 		artfDependencyMap[dependency[0]], _ = strconv.Atoi(dependency[1])
 	}
 
-	id, err := client.StartBuild(
-		c.String("pipeline"),
-		c.String("branch"),
-		c.String("comment"),
-		paramsMap,
-		snapDependencyMap,
-		artfDependencyMap,
-	)
+	options := teamcity.StartBuildOptions{
+		BuildTypeID:            c.String("pipeline"),
+		Branch:                 c.String("branch"),
+		Comment:                c.String("comment"),
+		Params:                 paramsMap,
+		SnapshotDependencies:   snapDependencyMap,
+		ArtifactDependencies:   artfDependencyMap,
+		ChangeID:               c.Int("change-id"),
+		Revision:               c.String("revision"),
+		VcsRootInstanceID:      c.String("vcs-root-instance"),
+		Personal:               c.Bool("personal"),
+		CleanSources:           c.Bool("clean-sources"),
+		RebuildAllDependencies: c.Bool("rebuild-deps"),
+		QueueAtTop:             c.Bool("queue-at-top"),
+		AgentID:                c.Int("agent-id"),
+		AgentName:              c.String("agent"),
+		AgentPoolID:            c.Int("agent-pool-id"),
+		Tags:                   c.StringSlice("tag"),
+	}
+
+	if c.Int("retries") > 0 {
+		return startBuildWithRetry(c, client, options)
+	}
+
+	id, err := client.StartBuildWithOptions(c.Context, options)
 	if err != nil {
 		log.Println(err.Error())
 		return err
@@ -79,6 +105,40 @@ func startBuild(c *cli.Context) error {
 	return nil
 }
 
+// startBuildWithRetry starts a build, waits for it and retries
+// it as per the retry flags
+func startBuildWithRetry(c *cli.Context, client *teamcity.TCClient, options teamcity.StartBuildOptions) error {
+	policy := teamcity.RetryPolicy{
+		MaxRetries:   c.Int("retries"),
+		PollInterval: c.Duration("poll-interval"),
+	}
+	if retryOn := c.String("retry-on"); retryOn != "" {
+		re, err := regexp.Compile(retryOn)
+		if err != nil {
+			log.Println(err.Error())
+			return err
+		}
+		policy.RetryOn = re
+	}
+
+	attempts, err := client.StartBuildWithRetry(c.Context, options, policy)
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "Build ID", "Status", "Status Text", "WebURL"})
+	for i, attempt := range attempts {
+		t.AppendRow([]interface{}{i + 1, attempt.ID, attempt.Status, attempt.StatusText, attempt.WebURL})
+	}
+	t.Render()
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	if last := attempts[len(attempts)-1]; last.Status != "SUCCESS" {
+		return fmt.Errorf("Build %d finished with status %s after %d attempts", last.ID, last.Status, len(attempts))
+	}
+	return nil
+}
+
 func cancelBuild(c *cli.Context) error {
 	client := teamcity.NewTeamcityClient(
 		5*time.Second,
@@ -186,6 +246,8 @@ func getBuilds(c *cli.Context) error {
 
 	running := c.Bool("running")
 	cancelled := c.Bool("cancelled")
+	pinned := c.Bool("pinned")
+	tags := c.StringSlice("tag")
 	page := c.Uint("page")
 	count := c.Uint("count")
 	if page > 1 {
@@ -199,6 +261,8 @@ func getBuilds(c *cli.Context) error {
 		User:        user,
 		Running:     running,
 		Cancelled:   cancelled,
+		Pinned:      pinned,
+		Tags:        tags,
 		Start:       page,
 		Count:       count,
 		LookupLimit: 0,
@@ -309,6 +373,64 @@ func main() {
 						Usage: "Provide text comment",
 						Value: "Build started by teamcityctl CLI",
 					},
+					&cli.StringFlag{
+						Name:  "revision",
+						Usage: "Provide VCS revision (commit SHA) to perform build upon",
+					},
+					&cli.StringFlag{
+						Name:  "vcs-root-instance",
+						Usage: "Provide VCS root instance ID the revision belongs to",
+					},
+					&cli.IntFlag{
+						Name:  "change-id",
+						Usage: "Provide teamcity change ID to perform build upon",
+					},
+					&cli.BoolFlag{
+						Name:  "personal",
+						Usage: "Start a personal build",
+					},
+					&cli.BoolFlag{
+						Name:  "clean-sources",
+						Usage: "Clean all files in the checkout directory before the build",
+					},
+					&cli.BoolFlag{
+						Name:  "rebuild-deps",
+						Usage: "Rebuild all snapshot dependencies instead of reusing suitable builds",
+					},
+					&cli.BoolFlag{
+						Name:  "queue-at-top",
+						Usage: "Put the build at the top of the build queue",
+					},
+					&cli.IntFlag{
+						Name:  "agent-id",
+						Usage: "Provide ID of the agent to run the build on",
+					},
+					&cli.StringFlag{
+						Name:  "agent",
+						Usage: "Provide name of the agent to run the build on",
+					},
+					&cli.IntFlag{
+						Name:  "agent-pool-id",
+						Usage: "Provide ID of the agent pool to run the build on",
+					},
+					&cli.StringSliceFlag{
+						Name:  "tag",
+						Usage: "Provide multiple tags to add to the build, e.g. --tag tag1 --tag tag2",
+					},
+					&cli.IntFlag{
+						Name:  "retries",
+						Usage: "Wait for the build and re-queue it up to this many times when it fails",
+					},
+					&cli.StringFlag{
+						Name: "retry-on",
+						Usage: "Provide regular expression matched against status text and build problems to decide on a retry," +
+							" e.g. \"agent disconnected|OutOfMemory\". Retries on infrastructure problems when not provided",
+					},
+					&cli.DurationFlag{
+						Name:  "poll-interval",
+						Usage: "Interval at which the build is polled when retrying",
+						Value: 10 * time.Second,
+					},
 				},
 				Action: startBuild,
 			},
@@ -361,6 +483,14 @@ func main() {
 						Name:  "cancelled",
 						Usage: "Show builds that are cancelled",
 					},
+					&cli.BoolFlag{
+						Name:  "pinned",
+						Usage: "Show builds that are pinned",
+					},
+					&cli.StringSliceFlag{
+						Name:  "tag",
+						Usage: "Show builds having all of these tags, e.g. --tag tag1 --tag tag2",
+					},
 					&cli.UintFlag{
 						Name:        "page",
 						Usage:       "Page number to show builds from. Paginates builds list",
@@ -430,6 +560,21 @@ func main() {
 				},
 				Action: fetchArtifact,
 			},
+			queueCommand,
+			cancelBuildsCommand,
+			rebuildCommand,
+			tagCommand,
+			pinCommand,
+			testsCommand,
+			flakyCommand,
+			changesCommand,
+			changelogCommand,
+			statisticsCommand,
+			reportCommand,
+			chainCommand,
+			runFlowCommand,
+			startMatrixCommand,
+			bisectCommand,
 		},
 	}
 	if err := app.Run(os.Args); err != nil {
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity/junit"
+	"github.com/urfave/cli/v2"
+)
+
+var testsCommand = &cli.Command{
+	Name:  "tests",
+	Usage: "List test results of a build",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:     "id",
+			Usage:    "Provide unique build ID whose test results are required",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "failed",
+			Usage: "Show failed tests only",
+		},
+		&cli.BoolFlag{
+			Name:  "new-failure",
+			Usage: "Show tests that failed for the first time only",
+		},
+		&cli.BoolFlag{
+			Name:  "details",
+			Usage: "Show failure details and stack traces in table format",
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "Provide format to render result. Supported formats: json, table, junit",
+			DefaultText: "json",
+		},
+	},
+	Action: listTests,
+}
+
+func listTests(c *cli.Context) error {
+	client := newClient(c, 30*time.Second)
+
+	params := teamcity.TCTestQueryParams{
+		NewFailure: c.Bool("new-failure"),
+	}
+	if c.Bool("failed") {
+		params.Status = "FAILURE"
+	}
+
+	occurrences, err := client.ListTestOccurrences(c.Context, c.Int("id"), params)
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	switch c.String("format") {
+	case "junit":
+		return junit.Encode(os.Stdout, fmt.Sprintf("build %d", c.Int("id")), occurrences)
+	case "table":
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Name", "Status", "Duration", "Flags"})
+		for _, occurrence := range occurrences {
+			t.AppendRow([]interface{}{
+				occurrence.Name,
+				occurrence.Status,
+				time.Duration(occurrence.Duration) * time.Millisecond,
+				testFlags(occurrence),
+			})
+			if c.Bool("details") && occurrence.Details != "" {
+				t.AppendRow([]interface{}{occurrence.Details})
+			}
+		}
+		t.AppendFooter(table.Row{"Total", len(occurrences)})
+		t.Render()
+	default:
+		jsonRender, _ := json.MarshalIndent(occurrences, "", "  ")
+		log.Println(string(jsonRender))
+	}
+	return nil
+}
+
+// testFlags describes whether a test occurrence is
+// ignored, muted or a new failure
+func testFlags(occurrence teamcity.TCTestOccurrence) string {
+	flags := []string{}
+	if occurrence.Ignored {
+		flags = append(flags, "ignored")
+	}
+	if occurrence.Muted {
+		flags = append(flags, "muted")
+	}
+	if occurrence.NewFailure {
+		flags = append(flags, "new failure")
+	}
+	return strings.Join(flags, ", ")
+}
@@ -8,4 +8,5 @@ require (
 	github.com/mattn/go-runewidth v0.0.9 // indirect
 	github.com/urfave/cli/v2 v2.2.0
 	golang.org/x/sys v0.0.0-20200930185726-fdedc70b468f // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,52 @@
+// Package cliutil parses the flag values shared by the command line tools
+package cliutil
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseParams parses params provided in the form of KEY=VALUE
+func ParseParams(values []string) (map[string]string, error) {
+	paramsMap := map[string]string{}
+	for _, v := range values {
+		param := strings.SplitN(v, "=", 2)
+		if len(param) != 2 {
+			return nil, errors.New("Params not provided in the form of KEY=VALUE")
+		}
+		paramsMap[param[0]] = param[1]
+	}
+	return paramsMap, nil
+}
+
+// ParseSince parses the start of a date window given either as a
+// duration before now such as 30d, 2w or 12h or as a date 2006-01-02.
+// Negative durations, which would lie in the future, are rejected
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if strings.HasSuffix(value, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(value, suffix))
+			if err != nil || n < 0 {
+				return time.Time{}, fmt.Errorf("Since %s is not a valid duration or date", value)
+			}
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("Since %s is not a valid duration or date", value)
+	}
+	return now.Add(-d), nil
+}
@@ -0,0 +1,52 @@
+package cliutil
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseParams(t *testing.T) {
+	params, err := ParseParams([]string{"env.A=1", "B=x=y", "C="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"env.A": "1", "B": "x=y", "C": ""}; !reflect.DeepEqual(params, want) {
+		t.Errorf("got params %v, want %v", params, want)
+	}
+
+	if _, err := ParseParams([]string{"A=1", "B"}); err == nil {
+		t.Error("expected an error for a param without value")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2020, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+		err   bool
+	}{
+		{value: "2020-03-01", want: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "12h", want: now.Add(-12 * time.Hour)},
+		{value: "90m", want: now.Add(-90 * time.Minute)},
+		{value: "5d", want: now.AddDate(0, 0, -5)},
+		{value: "2w", want: now.AddDate(0, 0, -14)},
+		{value: "0d", want: now},
+		{value: "-5d", err: true},
+		{value: "-2w", err: true},
+		{value: "-2h", err: true},
+		{value: "5x", err: true},
+		{value: "d", err: true},
+		{value: "2020-13-01", err: true},
+		{value: "", err: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseSince(test.value, now)
+		if (err != nil) != test.err || !got.Equal(test.want) {
+			t.Errorf("ParseSince(%q) = %v, %v", test.value, got, err)
+		}
+	}
+}
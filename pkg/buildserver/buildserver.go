@@ -1,10 +1,63 @@
 package buildserver
 
-// BuildServer ...
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotSupported is returned when a build server has no
+// equivalent for a requested feature
+var ErrNotSupported = errors.New("not supported by the build server")
+
+// BuildServer is the backend neutral API to trigger and
+// track builds on a build server
 type BuildServer interface {
-	GetBuild(int, interface{}) error
-	StartBuild(string, string, string, map[string]string, map[string]int, map[string]int) (int, error)
-	CancelQueuedBuild(int, string) error
-	StopBuild(int, string) error
-	GetArtifactTextFile(string, int) ([]byte, string, error)
+	// StartBuild adds a build to the queue of the build server
+	StartBuild(ctx context.Context, request TriggerRequest) (Build, error)
+	// GetBuild returns the current details of a build
+	GetBuild(ctx context.Context, id string) (Build, error)
+	// ListBuilds returns the builds matching the query, newest first
+	ListBuilds(ctx context.Context, query Query) ([]Build, error)
+	// WaitForBuild polls a build until it is finished
+	WaitForBuild(ctx context.Context, id string, pollInterval time.Duration) (Build, error)
+	// CancelQueuedBuild removes a build that has not started yet from the queue
+	CancelQueuedBuild(ctx context.Context, id, comment string) error
+	// StopBuild stops a running build
+	StopBuild(ctx context.Context, id, comment string) error
+	// GetBuildLog returns the log of a build. The caller closes the log
+	GetBuildLog(ctx context.Context, id string) (io.ReadCloser, error)
+	// ListArtifacts returns the files published by a build
+	ListArtifacts(ctx context.Context, id string) ([]Artifact, error)
+	// GetArtifact returns the content of a file published by a build. The caller closes the content
+	GetArtifact(ctx context.Context, id, path string) (io.ReadCloser, error)
+}
+
+// DefaultPollInterval is the interval at which builds are polled while
+// waiting for them when no interval is provided
+const DefaultPollInterval = 10 * time.Second
+
+// Poll calls get every pollInterval until the returned build is finished.
+// It helps build servers implement WaitForBuild on top of GetBuild
+func Poll(ctx context.Context, pollInterval time.Duration, get func(ctx context.Context) (Build, error)) (Build, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		build, err := get(ctx)
+		if err != nil || build.Finished() {
+			return build, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return build, ctx.Err()
+		case <-ticker.C:
+		}
+	}
 }
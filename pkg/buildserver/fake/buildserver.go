@@ -0,0 +1,222 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+)
+
+// StartBuild queues a build. Builds listed in ArtifactsFrom must exist
+func (s *Server) StartBuild(ctx context.Context, request buildserver.TriggerRequest) (buildserver.Build, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.scriptedError("StartBuild"); err != nil {
+		return buildserver.Build{}, err
+	}
+	if request.Pipeline == "" {
+		return buildserver.Build{}, errors.New("a build needs a pipeline")
+	}
+	for _, id := range request.ArtifactsFrom {
+		if _, err := s.lookup(id); err != nil {
+			return buildserver.Build{}, err
+		}
+	}
+
+	s.triggered = append(s.triggered, request)
+	return copyBuild(s.add(request).build), nil
+}
+
+// GetBuild returns the current details of a build
+func (s *Server) GetBuild(ctx context.Context, id string) (buildserver.Build, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.scriptedError("GetBuild"); err != nil {
+		return buildserver.Build{}, err
+	}
+	e, err := s.lookup(id)
+	if err != nil {
+		return buildserver.Build{}, err
+	}
+	return copyBuild(e.build), nil
+}
+
+// ListBuilds returns the builds matching the query, newest first
+func (s *Server) ListBuilds(ctx context.Context, query buildserver.Query) ([]buildserver.Build, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.scriptedError("ListBuilds"); err != nil {
+		return nil, err
+	}
+
+	builds := []buildserver.Build{}
+	for i := len(s.builds) - 1; i >= 0; i-- {
+		e := s.builds[i]
+		build := e.build
+
+		since := build.StartedAt
+		if since.IsZero() {
+			since = build.QueuedAt
+		}
+
+		switch {
+		case query.Pipeline != "" && build.Pipeline != query.Pipeline,
+			query.Branch != "" && build.Branch != query.Branch,
+			query.User != "" && e.user != query.User,
+			query.State != "" && build.State != query.State,
+			query.Status != "" && build.Status != query.Status,
+			!query.Since.IsZero() && since.Before(query.Since):
+			continue
+		}
+
+		builds = append(builds, copyBuild(build))
+		if query.Limit > 0 && len(builds) >= query.Limit {
+			break
+		}
+	}
+	return builds, nil
+}
+
+// WaitForBuild waits until a build is finished without sleeping. Builds of
+// scripted pipelines are advanced by moving the simulated clock forward to
+// their next start or finish, pollInterval is not used for them. Other
+// builds are waited for until they are changed by another goroutine or ctx
+// is done
+func (s *Server) WaitForBuild(ctx context.Context, id string, pollInterval time.Duration) (buildserver.Build, error) {
+	s.mu.Lock()
+	err := s.scriptedError("WaitForBuild")
+	s.mu.Unlock()
+	if err != nil {
+		return buildserver.Build{}, err
+	}
+
+	for {
+		s.mu.Lock()
+		e, err := s.lookup(id)
+		if err != nil {
+			s.mu.Unlock()
+			return buildserver.Build{}, err
+		}
+		build, changed := copyBuild(e.build), s.changed
+		if !build.Finished() && e.script != nil && ctx.Err() == nil {
+			// Moving the clock to the next event of this build under the
+			// lock keeps concurrent waiters from moving it any further
+			s.advanceTo(e.next())
+			s.mu.Unlock()
+			continue
+		}
+		s.mu.Unlock()
+
+		if build.Finished() {
+			return build, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return build, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// CancelQueuedBuild cancels a build that has not started yet
+func (s *Server) CancelQueuedBuild(ctx context.Context, id, comment string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.scriptedError("CancelQueuedBuild")
+	if err == nil {
+		err = s.cancel(id, comment, buildserver.StateQueued)
+	}
+	s.cancelled = append(s.cancelled, Call{ID: id, Comment: comment, At: s.now, Err: err})
+	return err
+}
+
+// StopBuild cancels a running build
+func (s *Server) StopBuild(ctx context.Context, id, comment string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.scriptedError("StopBuild")
+	if err == nil {
+		err = s.cancel(id, comment, buildserver.StateRunning)
+	}
+	s.stopped = append(s.stopped, Call{ID: id, Comment: comment, At: s.now, Err: err})
+	return err
+}
+
+// cancel finishes a build in state as cancelled. The caller holds the lock
+func (s *Server) cancel(id, comment string, state buildserver.BuildState) error {
+	e, err := s.lookup(id)
+	if err != nil {
+		return err
+	}
+	if e.build.State != state {
+		return fmt.Errorf("build %s is %s, not %s", id, e.build.State, state)
+	}
+	s.finish(e, s.now, buildserver.StatusCancelled, comment)
+	return nil
+}
+
+// GetBuildLog returns the log of a build as written so far
+func (s *Server) GetBuildLog(ctx context.Context, id string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.scriptedError("GetBuildLog"); err != nil {
+		return nil, err
+	}
+	e, err := s.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewBufferString(e.log.String())), nil
+}
+
+// ListArtifacts returns the artifacts of a build sorted by path
+func (s *Server) ListArtifacts(ctx context.Context, id string) ([]buildserver.Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.scriptedError("ListArtifacts"); err != nil {
+		return nil, err
+	}
+	e, err := s.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := []buildserver.Artifact{}
+	for _, path := range sortedPaths(e.artifacts) {
+		artifacts = append(artifacts, buildserver.Artifact{Path: path, Size: int64(len(e.artifacts[path]))})
+	}
+	return artifacts, nil
+}
+
+// GetArtifact returns the content of an artifact of a build
+func (s *Server) GetArtifact(ctx context.Context, id, path string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.scriptedError("GetArtifact"); err != nil {
+		return nil, err
+	}
+	e, err := s.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	content, ok := e.artifacts[path]
+	if !ok {
+		return nil, fmt.Errorf("artifact %s of build %s: %w", path, id, ErrNotFound)
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
@@ -0,0 +1,399 @@
+/*
+Package fake provides an in-memory buildserver.BuildServer for the unit
+tests of code built on top of this library
+
+Builds are queued by StartBuild and advanced through their states either
+on demand with Start and Finish or on a simulated clock with Advance,
+following the Script of their pipeline:
+
+	server := fake.New()
+	server.SetScript("Project_Build", fake.Script{
+		QueueTime: time.Minute,
+		RunTime:   10 * time.Minute,
+		Statuses:  []buildserver.BuildStatus{buildserver.StatusFailure, buildserver.StatusSuccess},
+		Artifacts: map[string][]byte{"report.txt": []byte("ok")},
+	})
+
+	build, _ := server.StartBuild(ctx, buildserver.TriggerRequest{Pipeline: "Project_Build"})
+	build, _ = server.WaitForBuild(ctx, build.ID, time.Minute) // fails, the next build succeeds
+
+WaitForBuild never sleeps. Builds of scripted pipelines are waited for by
+advancing the simulated clock to their next start or finish, never past
+it, so that concurrent waiters leave the clock at the same time whatever
+order they run in. Other builds are waited for by blocking until Start,
+Finish, CancelQueuedBuild or StopBuild changes them.
+
+Calls to CancelQueuedBuild and StopBuild are recorded, and FailNext
+scripts errors returned by the next calls of a method.
+*/
+package fake
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+)
+
+// ErrNotFound is returned for builds and artifacts the fake does not know
+var ErrNotFound = errors.New("not found")
+
+// Epoch is the time the simulated clock of a new fake starts at
+var Epoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Script describes how the builds of a pipeline advance on the
+// simulated clock and how they end
+type Script struct {
+	QueueTime  time.Duration             // Time a build waits in the queue before it starts
+	RunTime    time.Duration             // Time a build runs before it finishes
+	Statuses   []buildserver.BuildStatus // Status of successive builds, the last one repeats. Success when empty
+	StatusText string                    // Status text of finished builds
+	Log        string                    // Log written while a build runs
+	Artifacts  map[string][]byte         // Artifacts published when a build finishes
+}
+
+// Call is a recorded call to CancelQueuedBuild or StopBuild
+type Call struct {
+	ID      string
+	Comment string
+	At      time.Time // Time on the simulated clock
+	Err     error     // Error returned by the call
+}
+
+type entry struct {
+	build     buildserver.Build
+	script    *Script
+	index     int    // Index of the build among the builds of its pipeline
+	user      string // User the build was triggered by
+	log       strings.Builder
+	artifacts map[string][]byte
+}
+
+// Server is an in-memory build server. It is safe for concurrent use
+type Server struct {
+	mu      sync.Mutex
+	now     time.Time
+	user    string
+	builds  []*entry
+	byID    map[string]*entry
+	counts  map[string]int
+	scripts map[string]Script
+	errs    map[string][]error
+
+	triggered []buildserver.TriggerRequest
+	cancelled []Call
+	stopped   []Call
+
+	// changed is closed and replaced whenever a build changes
+	changed chan struct{}
+}
+
+var _ buildserver.BuildServer = (*Server)(nil)
+
+// New creates an empty fake build server with its clock at Epoch
+func New() *Server {
+	return &Server{
+		now:     Epoch,
+		byID:    map[string]*entry{},
+		counts:  map[string]int{},
+		scripts: map[string]Script{},
+		errs:    map[string][]error{},
+		changed: make(chan struct{}),
+	}
+}
+
+// Now returns the time on the simulated clock
+func (s *Server) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+// SetUser sets the user builds started from now on are triggered
+// by, as matched by Query.User
+func (s *Server) SetUser(user string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = user
+}
+
+// SetScript makes builds of a pipeline started from now on advance on the
+// simulated clock. Builds of pipelines without a script only advance on demand
+func (s *Server) SetScript(pipeline string, script Script) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[pipeline] = script
+}
+
+// FailNext makes the next call of a BuildServer method, given by its
+// name such as "StartBuild", return err. Errors of several calls queue up
+func (s *Server) FailNext(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs[method] = append(s.errs[method], err)
+}
+
+// Advance moves the simulated clock forward, starting and finishing
+// the builds of scripted pipelines whose time has come
+func (s *Server) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advanceTo(s.now.Add(d))
+}
+
+// advanceTo moves the simulated clock forward to t, it stays where it
+// is when it is already past t. The caller holds the lock
+func (s *Server) advanceTo(t time.Time) {
+	if t.After(s.now) {
+		s.now = t
+	}
+	for _, e := range s.builds {
+		if e.script == nil {
+			continue
+		}
+		if e.build.State == buildserver.StateQueued {
+			startAt := e.build.QueuedAt.Add(e.script.QueueTime)
+			if s.now.Before(startAt) {
+				continue
+			}
+			s.start(e, startAt)
+		}
+		if e.build.State == buildserver.StateRunning {
+			finishAt := e.build.StartedAt.Add(e.script.RunTime)
+			if s.now.Before(finishAt) {
+				continue
+			}
+			s.finish(e, finishAt, e.script.status(e.index), e.script.StatusText)
+		}
+	}
+}
+
+// Start starts a queued build
+func (s *Server) Start(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.lookup(id)
+	if err != nil {
+		return err
+	}
+	if e.build.State != buildserver.StateQueued {
+		return fmt.Errorf("build %s is %s, not queued", id, e.build.State)
+	}
+	s.start(e, s.now)
+	return nil
+}
+
+// Finish finishes a queued or running build with a status
+func (s *Server) Finish(id string, status buildserver.BuildStatus, statusText string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.lookup(id)
+	if err != nil {
+		return err
+	}
+	if e.build.Finished() {
+		return fmt.Errorf("build %s is already finished", id)
+	}
+	if e.build.State == buildserver.StateQueued {
+		s.start(e, s.now)
+	}
+	s.finish(e, s.now, status, statusText)
+	return nil
+}
+
+// SetArtifact publishes an artifact of a build, replacing an existing one
+func (s *Server) SetArtifact(id, path string, content []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.lookup(id)
+	if err != nil {
+		return err
+	}
+	e.artifacts[path] = append([]byte{}, content...)
+	return nil
+}
+
+// AppendLog writes text to the log of a build
+func (s *Server) AppendLog(id, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.lookup(id)
+	if err != nil {
+		return err
+	}
+	e.log.WriteString(text)
+	return nil
+}
+
+// Builds returns all builds, oldest first
+func (s *Server) Builds() []buildserver.Build {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	builds := make([]buildserver.Build, 0, len(s.builds))
+	for _, e := range s.builds {
+		builds = append(builds, copyBuild(e.build))
+	}
+	return builds
+}
+
+// Triggered returns the requests of all successful StartBuild calls
+func (s *Server) Triggered() []buildserver.TriggerRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]buildserver.TriggerRequest{}, s.triggered...)
+}
+
+// Cancelled returns all calls to CancelQueuedBuild, including failed ones
+func (s *Server) Cancelled() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call{}, s.cancelled...)
+}
+
+// Stopped returns all calls to StopBuild, including failed ones
+func (s *Server) Stopped() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call{}, s.stopped...)
+}
+
+// add queues a new build. The caller holds the lock
+func (s *Server) add(request buildserver.TriggerRequest) *entry {
+	s.counts[request.Pipeline]++
+
+	e := &entry{
+		build: buildserver.Build{
+			ID:       strconv.Itoa(len(s.builds) + 1),
+			Pipeline: request.Pipeline,
+			Number:   strconv.Itoa(s.counts[request.Pipeline]),
+			Branch:   request.Branch,
+			Revision: request.Revision,
+			State:    buildserver.StateQueued,
+			Status:   buildserver.StatusUnknown,
+			Params:   copyParams(request.Params),
+			QueuedAt: s.now,
+		},
+		index:     s.counts[request.Pipeline] - 1,
+		user:      s.user,
+		artifacts: map[string][]byte{},
+	}
+	if script, ok := s.scripts[request.Pipeline]; ok {
+		e.script = &script
+	}
+	e.build.WebURL = fmt.Sprintf("fake://builds/%s", e.build.ID)
+
+	s.builds = append(s.builds, e)
+	s.byID[e.build.ID] = e
+	s.logf(e, s.now, "Build queued")
+	s.notify()
+	return e
+}
+
+// start moves a queued build to running. The caller holds the lock
+func (s *Server) start(e *entry, at time.Time) {
+	e.build.State = buildserver.StateRunning
+	e.build.StartedAt = at
+	s.logf(e, at, "Build started")
+	if e.script != nil {
+		e.log.WriteString(e.script.Log)
+	}
+	s.notify()
+}
+
+// finish moves a build to finished. The caller holds the lock
+func (s *Server) finish(e *entry, at time.Time, status buildserver.BuildStatus, statusText string) {
+	e.build.State = buildserver.StateFinished
+	e.build.Status = status
+	e.build.StatusText = statusText
+	e.build.FinishedAt = at
+	if e.script != nil && status != buildserver.StatusCancelled {
+		for path, content := range e.script.Artifacts {
+			e.artifacts[path] = append([]byte{}, content...)
+		}
+	}
+	s.logf(e, at, "Build finished with status %s", status)
+	s.notify()
+}
+
+func (s *Server) logf(e *entry, at time.Time, format string, args ...interface{}) {
+	fmt.Fprintf(&e.log, "[%s] %s\n", at.Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
+// notify wakes up waiting WaitForBuild calls. The caller holds the lock
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// lookup returns the build with an ID. The caller holds the lock
+func (s *Server) lookup(id string) (*entry, error) {
+	e, ok := s.byID[id]
+	if !ok {
+		return nil, fmt.Errorf("build %s: %w", id, ErrNotFound)
+	}
+	return e, nil
+}
+
+// scriptedError pops the next error scripted for a method. The caller holds the lock
+func (s *Server) scriptedError(method string) error {
+	errs := s.errs[method]
+	if len(errs) == 0 {
+		return nil
+	}
+	s.errs[method] = errs[1:]
+	return errs[0]
+}
+
+// next returns the time a scripted build starts when it is queued and
+// finishes when it is running
+func (e *entry) next() time.Time {
+	if e.build.State == buildserver.StateQueued {
+		return e.build.QueuedAt.Add(e.script.QueueTime)
+	}
+	return e.build.StartedAt.Add(e.script.RunTime)
+}
+
+func (script *Script) status(index int) buildserver.BuildStatus {
+	if len(script.Statuses) == 0 {
+		return buildserver.StatusSuccess
+	}
+	if index >= len(script.Statuses) {
+		index = len(script.Statuses) - 1
+	}
+	return script.Statuses[index]
+}
+
+func copyBuild(build buildserver.Build) buildserver.Build {
+	build.Params = copyParams(build.Params)
+	return build
+}
+
+func copyParams(params map[string]string) map[string]string {
+	if params == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(params))
+	for k, v := range params {
+		copied[k] = v
+	}
+	return copied
+}
+
+func sortedPaths(artifacts map[string][]byte) []string {
+	paths := make([]string, 0, len(artifacts))
+	for path := range artifacts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,369 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+)
+
+func start(t *testing.T, s *Server, pipeline string) buildserver.Build {
+	build, err := s.StartBuild(context.Background(), buildserver.TriggerRequest{Pipeline: pipeline})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return build
+}
+
+func get(t *testing.T, s *Server, id string) buildserver.Build {
+	build, err := s.GetBuild(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return build
+}
+
+func TestAdvance(t *testing.T) {
+	s := New()
+	s.SetScript("P1", Script{QueueTime: time.Minute, RunTime: 10 * time.Minute})
+	build := start(t, s, "P1")
+	unscripted := start(t, s, "P2")
+
+	steps := []struct {
+		advance  time.Duration
+		state    buildserver.BuildState
+		started  time.Time
+		finished time.Time
+	}{
+		{time.Minute - time.Nanosecond, buildserver.StateQueued, time.Time{}, time.Time{}},
+		{time.Nanosecond, buildserver.StateRunning, Epoch.Add(time.Minute), time.Time{}},
+		{10*time.Minute - time.Nanosecond, buildserver.StateRunning, Epoch.Add(time.Minute), time.Time{}},
+		{time.Nanosecond, buildserver.StateFinished, Epoch.Add(time.Minute), Epoch.Add(11 * time.Minute)},
+	}
+	for i, step := range steps {
+		s.Advance(step.advance)
+		got := get(t, s, build.ID)
+		if got.State != step.state || !got.StartedAt.Equal(step.started) || !got.FinishedAt.Equal(step.finished) {
+			t.Errorf("step %d: got %s started %v finished %v, want %s started %v finished %v",
+				i, got.State, got.StartedAt, got.FinishedAt, step.state, step.started, step.finished)
+		}
+	}
+	if got := get(t, s, build.ID); got.Status != buildserver.StatusSuccess {
+		t.Errorf("got status %s, want success without statuses", got.Status)
+	}
+
+	// A single large step starts and finishes a build at the times of its script
+	second := start(t, s, "P1")
+	s.Advance(time.Hour)
+	got := get(t, s, second.ID)
+	queued := Epoch.Add(11 * time.Minute)
+	if got.State != buildserver.StateFinished || !got.StartedAt.Equal(queued.Add(time.Minute)) || !got.FinishedAt.Equal(queued.Add(11*time.Minute)) {
+		t.Errorf("got build %+v, want it finished at the times of the script", got)
+	}
+
+	if got := get(t, s, unscripted.ID); got.State != buildserver.StateQueued {
+		t.Errorf("got unscripted build %s, want it queued until started on demand", got.State)
+	}
+	if !s.Now().Equal(Epoch.Add(71 * time.Minute)) {
+		t.Errorf("got clock %v", s.Now())
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	s := New()
+	s.SetScript("P1", Script{
+		RunTime:    time.Minute,
+		Statuses:   []buildserver.BuildStatus{buildserver.StatusFailure, buildserver.StatusSuccess, buildserver.StatusFailure},
+		StatusText: "scripted",
+	})
+	s.SetScript("P2", Script{RunTime: time.Minute, Statuses: []buildserver.BuildStatus{buildserver.StatusCancelled}})
+
+	// Builds of the pipelines interleave, each pipeline cycles through its own statuses
+	pipelines := []string{"P1", "P2", "P1", "P1", "P2", "P1", "P1"}
+	want := []buildserver.BuildStatus{
+		buildserver.StatusFailure, buildserver.StatusCancelled, buildserver.StatusSuccess, buildserver.StatusFailure,
+		buildserver.StatusCancelled, buildserver.StatusFailure, buildserver.StatusFailure,
+	}
+	for i, pipeline := range pipelines {
+		build := start(t, s, pipeline)
+		build, err := s.WaitForBuild(context.Background(), build.ID, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if build.Status != want[i] || build.Pipeline != pipeline {
+			t.Errorf("build %d of %s: got status %s, want %s", i+1, pipeline, build.Status, want[i])
+		}
+		if pipeline == "P1" && build.StatusText != "scripted" {
+			t.Errorf("build %d: got status text %q", i+1, build.StatusText)
+		}
+	}
+}
+
+func TestWaitForBuildUnscripted(t *testing.T) {
+	s := New()
+	build := start(t, s, "P1")
+
+	done := make(chan buildserver.Build)
+	errs := make(chan error)
+	go func() {
+		build, err := s.WaitForBuild(context.Background(), build.ID, time.Second)
+		if err != nil {
+			errs <- err
+			return
+		}
+		done <- build
+	}()
+
+	// Give the waiter time to block, it returns either way
+	time.Sleep(10 * time.Millisecond)
+	if err := s.Start(build.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Finish(build.ID, buildserver.StatusFailure, "tests failed"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-done:
+		if got.Status != buildserver.StatusFailure || got.StatusText != "tests failed" {
+			t.Errorf("got build %+v", got)
+		}
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitForBuild was not woken by Finish")
+	}
+	if !s.Now().Equal(Epoch) {
+		t.Errorf("got clock %v, want waiting for an unscripted build not to advance it", s.Now())
+	}
+}
+
+func TestWaitForBuildConcurrent(t *testing.T) {
+	s := New()
+	s.SetScript("P1", Script{QueueTime: time.Minute, RunTime: 10 * time.Minute})
+	s.SetScript("P2", Script{RunTime: 3 * time.Minute})
+
+	ids := []string{}
+	for i := 0; i < 4; i++ {
+		ids = append(ids, start(t, s, "P1").ID, start(t, s, "P2").ID)
+	}
+
+	// However the waiters interleave, the clock stops at the last finish
+	var wg sync.WaitGroup
+	builds := make([]buildserver.Build, len(ids))
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			build, err := s.WaitForBuild(context.Background(), id, time.Second)
+			if err != nil {
+				t.Error(err)
+			}
+			builds[i] = build
+		}(i, id)
+	}
+	wg.Wait()
+
+	if !s.Now().Equal(Epoch.Add(11 * time.Minute)) {
+		t.Errorf("got clock %v, want the finish of the P1 builds", s.Now())
+	}
+	for _, build := range builds {
+		finish := Epoch.Add(11 * time.Minute)
+		if build.Pipeline == "P2" {
+			finish = Epoch.Add(3 * time.Minute)
+		}
+		if !build.FinishedAt.Equal(finish) {
+			t.Errorf("got build %+v, want it finished at %v", build, finish)
+		}
+	}
+}
+
+func TestListBuildsUser(t *testing.T) {
+	s := New()
+	s.SetUser("alice")
+	first := start(t, s, "P1")
+	s.SetUser("bob")
+	second := start(t, s, "P1")
+
+	for user, want := range map[string]string{"alice": first.ID, "bob": second.ID} {
+		builds, err := s.ListBuilds(context.Background(), buildserver.Query{User: user})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(builds) != 1 || builds[0].ID != want {
+			t.Errorf("%s: got builds %+v, want build %s", user, builds, want)
+		}
+	}
+}
+
+func TestWaitForBuildCancel(t *testing.T) {
+	s := New()
+	build := start(t, s, "P1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		got, err := s.WaitForBuild(ctx, build.ID, time.Second)
+		if got.State != buildserver.StateQueued {
+			t.Errorf("got build %+v, want it still queued", got)
+		}
+		errs <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitForBuild did not return when ctx was cancelled")
+	}
+}
+
+func TestFailNext(t *testing.T) {
+	s := New()
+	first, second := errors.New("first"), errors.New("second")
+	s.FailNext("StartBuild", first)
+	s.FailNext("StartBuild", second)
+	s.FailNext("GetBuild", second)
+
+	ctx := context.Background()
+	request := buildserver.TriggerRequest{Pipeline: "P1"}
+	if _, err := s.StartBuild(ctx, request); err != first {
+		t.Errorf("got error %v, want the first scripted error", err)
+	}
+	if _, err := s.StartBuild(ctx, request); err != second {
+		t.Errorf("got error %v, want the second scripted error", err)
+	}
+	build, err := s.StartBuild(ctx, request)
+	if err != nil {
+		t.Fatalf("got error %v, want the scripted errors to be used up", err)
+	}
+
+	if _, err := s.GetBuild(ctx, build.ID); err != second {
+		t.Errorf("got error %v, want the error scripted for GetBuild", err)
+	}
+	if _, err := s.GetBuild(ctx, build.ID); err != nil {
+		t.Error(err)
+	}
+	if triggered := s.Triggered(); len(triggered) != 1 {
+		t.Errorf("got %d triggered builds, want failed calls not to be recorded", len(triggered))
+	}
+}
+
+func TestCancelledAndStopped(t *testing.T) {
+	s := New()
+	queued := start(t, s, "P1")
+	running := start(t, s, "P1")
+	if err := s.Start(running.ID); err != nil {
+		t.Fatal(err)
+	}
+	s.Advance(time.Minute)
+	ctx := context.Background()
+
+	denied := errors.New("denied")
+	s.FailNext("CancelQueuedBuild", denied)
+	if err := s.CancelQueuedBuild(ctx, queued.ID, "first"); err != denied {
+		t.Errorf("got error %v, want the scripted error", err)
+	}
+	if err := s.CancelQueuedBuild(ctx, running.ID, "running"); err == nil {
+		t.Error("expected an error cancelling a running build")
+	}
+	if err := s.CancelQueuedBuild(ctx, queued.ID, "not needed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StopBuild(ctx, "42", "unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want ErrNotFound", err)
+	}
+	if err := s.StopBuild(ctx, running.ID, "stopped"); err != nil {
+		t.Fatal(err)
+	}
+
+	at := Epoch.Add(time.Minute)
+	cancelled := s.Cancelled()
+	if len(cancelled) != 3 ||
+		cancelled[0] != (Call{ID: queued.ID, Comment: "first", At: at, Err: denied}) ||
+		cancelled[1].ID != running.ID || cancelled[1].Err == nil ||
+		cancelled[2] != (Call{ID: queued.ID, Comment: "not needed", At: at}) {
+		t.Errorf("got cancelled calls %+v", cancelled)
+	}
+	stopped := s.Stopped()
+	if len(stopped) != 2 || !errors.Is(stopped[0].Err, ErrNotFound) || stopped[1] != (Call{ID: running.ID, Comment: "stopped", At: at}) {
+		t.Errorf("got stopped calls %+v", stopped)
+	}
+
+	for _, id := range []string{queued.ID, running.ID} {
+		if build := get(t, s, id); build.Status != buildserver.StatusCancelled || !build.FinishedAt.Equal(at) {
+			t.Errorf("got build %+v, want it cancelled", build)
+		}
+	}
+}
+
+func TestArtifacts(t *testing.T) {
+	s := New()
+	s.SetScript("P1", Script{
+		QueueTime: time.Minute,
+		RunTime:   time.Minute,
+		Artifacts: map[string][]byte{"report.txt": []byte("ok"), "bin/app": []byte("binary")},
+	})
+	finished := start(t, s, "P1")
+	cancelled := start(t, s, "P1")
+	stopped := start(t, s, "P1")
+	ctx := context.Background()
+
+	if err := s.CancelQueuedBuild(ctx, cancelled.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	s.Advance(time.Minute)
+	if err := s.StopBuild(ctx, stopped.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	artifacts, err := s.ListArtifacts(ctx, finished.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 0 {
+		t.Errorf("got artifacts %+v of a running build, want none", artifacts)
+	}
+
+	s.Advance(time.Minute)
+	artifacts, err = s.ListArtifacts(ctx, finished.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []buildserver.Artifact{{Path: "bin/app", Size: 6}, {Path: "report.txt", Size: 2}}
+	if !reflect.DeepEqual(artifacts, want) {
+		t.Errorf("got artifacts %+v, want %+v", artifacts, want)
+	}
+
+	content, err := s.GetArtifact(ctx, finished.ID, "report.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(content)
+	if string(data) != "ok" {
+		t.Errorf("got %q, want the content of the script", data)
+	}
+
+	for _, id := range []string{cancelled.ID, stopped.ID} {
+		artifacts, err := s.ListArtifacts(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(artifacts) != 0 {
+			t.Errorf("build %s: got artifacts %+v, want none for a cancelled build", id, artifacts)
+		}
+		if _, err := s.GetArtifact(ctx, id, "report.txt"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("build %s: got error %v, want ErrNotFound", id, err)
+		}
+	}
+}
@@ -0,0 +1,538 @@
+/*
+Package githubactions implements buildserver.BuildServer for GitHub Actions
+
+Pipelines are workflows given as "<owner>/<repo>/<workflow>", where the
+workflow is its file name, e.g. "octo/app/release.yml", or its ID. Queries
+may also name only "<owner>/<repo>" to list the runs of all workflows.
+Build IDs have the form "<owner>/<repo>#<run id>".
+
+StartBuild dispatches a workflow_dispatch event with the params as inputs.
+GitHub does not return the run it creates, hence the dispatch is
+correlated to the oldest run of the workflow on the same ref, triggered
+by the user of the token, that appears after it. Runs dispatched by other
+users are never picked, concurrent dispatches of the same workflow on the
+same ref by the same user may be picked up instead.
+
+Artifacts are the zip archives uploaded by a run and are
+listed and downloaded as "<artifact name>.zip".
+*/
+package githubactions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/internal/httpapi"
+)
+
+// DefaultServerURL is the API of github.com. GitHub Enterprise
+// Server serves it at https://<host>/api/v3
+const DefaultServerURL = "https://api.github.com"
+
+const (
+	defaultPageSize = 100
+
+	// dispatchTimeout bounds the time waited for the run of a dispatch
+	dispatchTimeout = time.Minute
+
+	// dispatchPollInterval is the interval at which runs are
+	// listed while waiting for the run of a dispatch
+	dispatchPollInterval = 2 * time.Second
+)
+
+// Client is client object to talk to github actions
+type Client struct {
+	api *httpapi.Client
+
+	dispatchTimeout      time.Duration
+	dispatchPollInterval time.Duration
+}
+
+var _ buildserver.BuildServer = (*Client)(nil)
+
+func init() {
+	buildserver.Register("githubactions", func(ctx context.Context, config buildserver.Config) (buildserver.BuildServer, error) {
+		return NewClient(
+			config.RequestTimeout,
+			config.DialTimeout,
+			config.TLSHandshakeTimeout,
+			config.ServerURL,
+			config.Token,
+			config.Insecure,
+		), nil
+	})
+}
+
+// NewClient creates a client authenticating with a token allowed to
+// run workflows. The API of github.com is used when serverURL is empty
+func NewClient(
+	requestTimeout, dialTimeout, tlsHandshakeTimeout time.Duration,
+	serverURL, token string,
+	insecure bool,
+) *Client {
+	if serverURL == "" {
+		serverURL = DefaultServerURL
+	}
+
+	return &Client{
+		api: &httpapi.Client{
+			HTTP:    httpapi.NewHTTPClient(requestTimeout, dialTimeout, tlsHandshakeTimeout, insecure),
+			BaseURL: strings.TrimSuffix(serverURL, "/"),
+			Prepare: func(ctx context.Context, req *http.Request) error {
+				req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+				req.Header.Add("Accept", "application/vnd.github+json")
+				req.Header.Add("X-GitHub-Api-Version", "2022-11-28")
+				return nil
+			},
+		},
+
+		dispatchTimeout:      dispatchTimeout,
+		dispatchPollInterval: dispatchPollInterval,
+	}
+}
+
+// StartBuild dispatches a workflow on the branch of the request, the default
+// branch of the repository when empty, with the params as inputs and returns
+// the run it created. The comment is not supported by github and ignored
+func (g *Client) StartBuild(ctx context.Context, request buildserver.TriggerRequest) (buildserver.Build, error) {
+	if request.Revision != "" || len(request.ArtifactsFrom) > 0 {
+		return buildserver.Build{}, fmt.Errorf("github actions revision and artifact dependencies: %w", buildserver.ErrNotSupported)
+	}
+
+	repo, workflow, err := parsePipeline(request.Pipeline)
+	if err != nil {
+		return buildserver.Build{}, err
+	}
+	if workflow == "" {
+		return buildserver.Build{}, fmt.Errorf("pipeline %s does not name a workflow", request.Pipeline)
+	}
+
+	ref := request.Branch
+	if ref == "" {
+		var repository GithubRepository
+		if err := g.doRequest(ctx, "GET", fmt.Sprintf("/repos/%s", repo), nil, &repository); err != nil {
+			return buildserver.Build{}, err
+		}
+		ref = repository.DefaultBranch
+	}
+
+	// Runs of other users are not the run of the dispatch. Tokens of
+	// github apps have no user, their runs are not filtered by actor
+	var actor string
+	var user GithubActor
+	if err := g.doRequest(ctx, "GET", "/user", nil, &user); err == nil {
+		actor = user.Login
+	}
+
+	// Runs that exist before the dispatch are not its run. Runs are
+	// listed from a minute earlier to allow for clock skew
+	since := time.Now().Add(-time.Minute)
+	existing, err := g.dispatchedRuns(ctx, repo, workflow, ref, actor, since)
+	if err != nil {
+		return buildserver.Build{}, err
+	}
+	known := map[int64]bool{}
+	for _, run := range existing {
+		known[run.ID] = true
+	}
+
+	payload := GithubDispatchPayload{Ref: ref, Inputs: request.Params}
+	if err := g.doRequest(ctx, "POST", fmt.Sprintf("/repos/%s/actions/workflows/%s/dispatches", repo, url.PathEscape(workflow)), payload, nil); err != nil {
+		return buildserver.Build{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.dispatchTimeout)
+	defer cancel()
+
+	for {
+		runs, err := g.dispatchedRuns(ctx, repo, workflow, ref, actor, since)
+		if err != nil && ctx.Err() == nil {
+			return buildserver.Build{}, err
+		}
+
+		// Runs are listed newest first, the oldest new run is the one of this dispatch
+		for i := len(runs) - 1; i >= 0; i-- {
+			if !known[runs[i].ID] {
+				build := toBuild(repo, runs[i])
+				build.Params = request.Params
+				return build, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return buildserver.Build{}, fmt.Errorf("no run of workflow %s appeared for the dispatch on %s: %w", workflow, ref, ctx.Err())
+		case <-time.After(g.dispatchPollInterval):
+		}
+	}
+}
+
+// dispatchedRuns returns the workflow_dispatch runs of a workflow on a
+// ref created after since by actor, or by anyone when empty, newest first
+func (g *Client) dispatchedRuns(ctx context.Context, repo, workflow, ref, actor string, since time.Time) ([]GithubWorkflowRun, error) {
+	filters := url.Values{}
+	filters.Set("event", "workflow_dispatch")
+	filters.Set("branch", ref)
+	if actor != "" {
+		filters.Set("actor", actor)
+	}
+	filters.Set("created", fmt.Sprintf(">=%s", since.UTC().Format(time.RFC3339)))
+	filters.Set("per_page", strconv.Itoa(defaultPageSize))
+
+	var runs GithubWorkflowRuns
+	err := g.doRequest(ctx, "GET", fmt.Sprintf("/repos/%s/actions/workflows/%s/runs?%s", repo, url.PathEscape(workflow), filters.Encode()), nil, &runs)
+	if err != nil || actor == "" {
+		return runs.WorkflowRuns, err
+	}
+
+	dispatched := []GithubWorkflowRun{}
+	for _, run := range runs.WorkflowRuns {
+		if run.Actor != nil && run.Actor.Login == actor {
+			dispatched = append(dispatched, run)
+		}
+	}
+	return dispatched, nil
+}
+
+// GetBuild returns the current details of a run. The status
+// text of a failed run names its failed jobs
+func (g *Client) GetBuild(ctx context.Context, id string) (buildserver.Build, error) {
+	repo, runID, err := parseID(id)
+	if err != nil {
+		return buildserver.Build{}, err
+	}
+
+	var run GithubWorkflowRun
+	if err := g.doRequest(ctx, "GET", fmt.Sprintf("/repos/%s/actions/runs/%d", repo, runID), nil, &run); err != nil {
+		return buildserver.Build{}, err
+	}
+	build := toBuild(repo, run)
+
+	if build.Status == buildserver.StatusFailure {
+		jobs, err := g.ListJobs(ctx, id)
+		if err != nil {
+			return buildserver.Build{}, err
+		}
+		failed := []string{}
+		for _, job := range jobs {
+			if job.Conclusion == "failure" || job.Conclusion == "timed_out" {
+				failed = append(failed, job.Name)
+			}
+		}
+		if len(failed) > 0 {
+			build.StatusText = fmt.Sprintf("failed jobs: %s", strings.Join(failed, ", "))
+		}
+	}
+	return build, nil
+}
+
+// ListJobs returns the jobs of the latest attempt of a run along with their status
+func (g *Client) ListJobs(ctx context.Context, id string) ([]GithubJob, error) {
+	repo, runID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []GithubJob{}
+	for page := 1; ; page++ {
+		var jobsPage GithubJobs
+		err := g.doRequest(ctx, "GET", fmt.Sprintf("/repos/%s/actions/runs/%d/jobs?filter=latest&per_page=%d&page=%d", repo, runID, defaultPageSize, page), nil, &jobsPage)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, jobsPage.Jobs...)
+		if len(jobsPage.Jobs) == 0 || len(jobs) >= jobsPage.TotalCount {
+			return jobs, nil
+		}
+	}
+}
+
+// ListBuilds returns the runs of the query workflow, or of all
+// workflows of the repository, newest first
+func (g *Client) ListBuilds(ctx context.Context, query buildserver.Query) ([]buildserver.Build, error) {
+	repo, workflow, err := parsePipeline(query.Pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+
+	filters := url.Values{}
+	filters.Set("per_page", strconv.Itoa(defaultPageSize))
+	if query.Branch != "" {
+		filters.Set("branch", query.Branch)
+	}
+	if query.User != "" {
+		filters.Set("actor", query.User)
+	}
+	if !query.Since.IsZero() {
+		filters.Set("created", fmt.Sprintf(">=%s", query.Since.UTC().Format(time.RFC3339)))
+	}
+	switch query.Status {
+	case buildserver.StatusSuccess:
+		filters.Set("status", "success")
+	case buildserver.StatusFailure:
+		filters.Set("status", "failure")
+	case buildserver.StatusCancelled:
+		filters.Set("status", "cancelled")
+	}
+	switch query.State {
+	case buildserver.StateRunning:
+		filters.Set("status", "in_progress")
+	case buildserver.StateFinished:
+		if query.Status == "" {
+			filters.Set("status", "completed")
+		}
+	}
+
+	runsPath := fmt.Sprintf("/repos/%s/actions/runs", repo)
+	if workflow != "" {
+		runsPath = fmt.Sprintf("/repos/%s/actions/workflows/%s/runs", repo, url.PathEscape(workflow))
+	}
+
+	builds := []buildserver.Build{}
+	for page, seen := 1, 0; len(builds) < limit; page++ {
+		filters.Set("page", strconv.Itoa(page))
+
+		var runs GithubWorkflowRuns
+		if err := g.doRequest(ctx, "GET", fmt.Sprintf("%s?%s", runsPath, filters.Encode()), nil, &runs); err != nil {
+			return nil, err
+		}
+
+		for _, run := range runs.WorkflowRuns {
+			build := toBuild(repo, run)
+			switch {
+			case query.State != "" && build.State != query.State,
+				query.Status != "" && build.Status != query.Status:
+				continue
+			}
+			builds = append(builds, build)
+			if len(builds) >= limit {
+				break
+			}
+		}
+
+		seen += len(runs.WorkflowRuns)
+		if len(runs.WorkflowRuns) == 0 || seen >= runs.TotalCount {
+			break
+		}
+	}
+	return builds, nil
+}
+
+// WaitForBuild polls a run until it is completed
+func (g *Client) WaitForBuild(ctx context.Context, id string, pollInterval time.Duration) (buildserver.Build, error) {
+	return buildserver.Poll(ctx, pollInterval, func(ctx context.Context) (buildserver.Build, error) {
+		return g.GetBuild(ctx, id)
+	})
+}
+
+// CancelQueuedBuild cancels a run that has not started yet. The comment
+// is not supported by github and ignored
+func (g *Client) CancelQueuedBuild(ctx context.Context, id, comment string) error {
+	return g.cancel(ctx, id)
+}
+
+// StopBuild cancels a running run. The comment
+// is not supported by github and ignored
+func (g *Client) StopBuild(ctx context.Context, id, comment string) error {
+	return g.cancel(ctx, id)
+}
+
+func (g *Client) cancel(ctx context.Context, id string) error {
+	repo, runID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return g.doRequest(ctx, "POST", fmt.Sprintf("/repos/%s/actions/runs/%d/cancel", repo, runID), nil, nil)
+}
+
+// GetBuildLog returns the logs of all jobs of a run in the order they
+// were started, each preceded by a line naming the job
+func (g *Client) GetBuildLog(ctx context.Context, id string) (io.ReadCloser, error) {
+	repo, _, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := g.ListJobs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+
+	logs := make([]httpapi.JobLog, 0, len(jobs))
+	for _, job := range jobs {
+		logs = append(logs, httpapi.JobLog{
+			Name:   job.Name,
+			Status: job.Status,
+			Path:   fmt.Sprintf("/repos/%s/actions/jobs/%d/logs", repo, job.ID),
+		})
+	}
+	return g.api.ConcatLogs(ctx, logs), nil
+}
+
+// ListArtifacts returns the artifacts of a run that have not expired
+func (g *Client) ListArtifacts(ctx context.Context, id string) ([]buildserver.Artifact, error) {
+	runArtifacts, err := g.listArtifacts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := []buildserver.Artifact{}
+	for _, artifact := range runArtifacts {
+		if artifact.Expired {
+			continue
+		}
+		artifacts = append(artifacts, buildserver.Artifact{
+			Path: fmt.Sprintf("%s.zip", artifact.Name),
+			Size: artifact.SizeInBytes,
+		})
+	}
+	return artifacts, nil
+}
+
+// GetArtifact returns the zip archive of an artifact of a run
+func (g *Client) GetArtifact(ctx context.Context, id, artifactPath string) (io.ReadCloser, error) {
+	repo, _, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	runArtifacts, err := g.listArtifacts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(artifactPath, ".zip")
+	for _, artifact := range runArtifacts {
+		if artifact.Name != name {
+			continue
+		}
+		if artifact.Expired {
+			return nil, fmt.Errorf("artifact %s of build %s has expired", name, id)
+		}
+		// GitHub redirects to the archive in blob storage, the
+		// authorization header is not forwarded to it
+		return g.api.Stream(ctx, fmt.Sprintf("/repos/%s/actions/artifacts/%d/zip", repo, artifact.ID))
+	}
+	return nil, fmt.Errorf("build %s has no artifact %s", id, name)
+}
+
+func (g *Client) listArtifacts(ctx context.Context, id string) ([]GithubArtifact, error) {
+	repo, runID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := []GithubArtifact{}
+	for page := 1; ; page++ {
+		var artifactsPage GithubArtifacts
+		err := g.doRequest(ctx, "GET", fmt.Sprintf("/repos/%s/actions/runs/%d/artifacts?per_page=%d&page=%d", repo, runID, defaultPageSize, page), nil, &artifactsPage)
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, artifactsPage.Artifacts...)
+		if len(artifactsPage.Artifacts) == 0 || len(artifacts) >= artifactsPage.TotalCount {
+			return artifacts, nil
+		}
+	}
+}
+
+func (g *Client) doRequest(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	_, err := g.api.DoJSON(ctx, method, path, payload, out)
+	return err
+}
+
+// StatusError is returned when github responds with a non 2xx status code
+type StatusError = httpapi.StatusError
+
+// parsePipeline splits a pipeline into repository, e.g. octo/app, and
+// workflow. The workflow is empty when the pipeline names only a repository
+func parsePipeline(pipeline string) (string, string, error) {
+	parts := strings.SplitN(pipeline, "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("%s is not a valid github actions pipeline, expected <owner>/<repo>/<workflow>", pipeline)
+	}
+
+	repo := fmt.Sprintf("%s/%s", parts[0], parts[1])
+	if len(parts) == 2 {
+		return repo, "", nil
+	}
+	return repo, parts[2], nil
+}
+
+// parseID splits a build ID into repository and run ID
+func parseID(id string) (string, int64, error) {
+	i := strings.LastIndex(id, "#")
+	if i < 1 {
+		return "", 0, fmt.Errorf("%s is not a valid github actions build id", id)
+	}
+
+	runID, err := strconv.ParseInt(id[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("%s is not a valid github actions build id", id)
+	}
+	return id[:i], runID, nil
+}
+
+// toBuild converts a workflow run to a neutral build
+func toBuild(repo string, run GithubWorkflowRun) buildserver.Build {
+	build := buildserver.Build{
+		ID:         fmt.Sprintf("%s#%d", repo, run.ID),
+		Pipeline:   repo,
+		Number:     strconv.Itoa(run.RunNumber),
+		Branch:     run.HeadBranch,
+		Revision:   run.HeadSHA,
+		StatusText: run.Status,
+		WebURL:     run.HTMLURL,
+		Status:     buildserver.StatusUnknown,
+	}
+
+	if run.Path != "" {
+		build.Pipeline = fmt.Sprintf("%s/%s", repo, path.Base(run.Path))
+	}
+
+	switch run.Status {
+	case "in_progress":
+		build.State = buildserver.StateRunning
+	case "completed":
+		build.State = buildserver.StateFinished
+		build.StatusText = run.Conclusion
+	default:
+		// queued, requested, waiting and pending
+		build.State = buildserver.StateQueued
+	}
+
+	switch run.Conclusion {
+	case "success":
+		build.Status = buildserver.StatusSuccess
+	case "failure", "timed_out", "startup_failure":
+		build.Status = buildserver.StatusFailure
+	case "cancelled":
+		build.Status = buildserver.StatusCancelled
+	}
+
+	if run.CreatedAt != nil {
+		build.QueuedAt = *run.CreatedAt
+	}
+	if run.RunStartedAt != nil && build.State != buildserver.StateQueued {
+		build.StartedAt = *run.RunStartedAt
+	}
+	if run.UpdatedAt != nil && build.State == buildserver.StateFinished {
+		build.FinishedAt = *run.UpdatedAt
+	}
+	return build
+}
@@ -0,0 +1,510 @@
+package githubactions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+)
+
+// fakeGithub is a stand-in for the github API of the repository octo/app
+type fakeGithub struct {
+	t *testing.T
+
+	mu         sync.Mutex
+	user       string // Login of the token, none for tokens of github apps
+	requests   []string
+	query      url.Values // Query of the last request
+	dispatches []GithubDispatchPayload
+	runs       []GithubWorkflowRun
+	jobs       []GithubJob
+	logs       map[int64]string
+	artifacts  []GithubArtifact
+	archives   map[int64]string
+
+	// appear holds the runs that show up once the workflow runs have
+	// been listed that many times after the dispatch
+	appear   map[int][]GithubWorkflowRun
+	listings int
+}
+
+func newFakeGithub(t *testing.T) *fakeGithub {
+	return &fakeGithub{
+		t:        t,
+		user:     "alice",
+		logs:     map[int64]string{},
+		archives: map[int64]string{},
+		appear:   map[int][]GithubWorkflowRun{},
+	}
+}
+
+func (f *fakeGithub) client() (*Client, *httptest.Server) {
+	server := httptest.NewServer(f)
+	client := NewClient(5*time.Second, 5*time.Second, 5*time.Second, server.URL, "token", false)
+	client.dispatchPollInterval = time.Millisecond
+	return client, server
+}
+
+func (f *fakeGithub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/repos/octo/app")
+	f.requests = append(f.requests, r.Method+" "+path)
+	f.query = r.URL.Query()
+
+	var id int
+	switch {
+	case path == "/user":
+		if f.user == "" {
+			http.Error(w, `{"message":"Resource not accessible by integration"}`, http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(GithubActor{Login: f.user})
+
+	case path == "":
+		json.NewEncoder(w).Encode(GithubRepository{FullName: "octo/app", DefaultBranch: "main"})
+
+	case r.Method == "POST" && path == "/actions/workflows/release.yml/dispatches":
+		var payload GithubDispatchPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.dispatches = append(f.dispatches, payload)
+		w.WriteHeader(http.StatusNoContent)
+
+	case path == "/actions/workflows/release.yml/runs":
+		if len(f.dispatches) > 0 {
+			f.listings++
+			f.runs = append(f.runs, f.appear[f.listings]...)
+		}
+		f.writeRuns(w, r)
+
+	case path == "/actions/runs":
+		f.writeRuns(w, r)
+
+	case scan(path, "/actions/runs/%d", &id):
+		for _, run := range f.runs {
+			if run.ID == int64(id) {
+				json.NewEncoder(w).Encode(run)
+				return
+			}
+		}
+		http.NotFound(w, r)
+
+	case scan(path, "/actions/runs/%d/cancel", &id):
+		w.WriteHeader(http.StatusAccepted)
+
+	case scan(path, "/actions/runs/%d/jobs", &id):
+		// Serve one job per page to exercise paging
+		page := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		jobs := []GithubJob{}
+		if page <= len(f.jobs) {
+			jobs = f.jobs[page-1 : page]
+		}
+		json.NewEncoder(w).Encode(GithubJobs{TotalCount: len(f.jobs), Jobs: jobs})
+
+	case scan(path, "/actions/jobs/%d/logs", &id):
+		fmt.Fprint(w, f.logs[int64(id)])
+
+	case scan(path, "/actions/runs/%d/artifacts", &id):
+		json.NewEncoder(w).Encode(GithubArtifacts{TotalCount: len(f.artifacts), Artifacts: f.artifacts})
+
+	case scan(path, "/actions/artifacts/%d/zip", &id):
+		fmt.Fprint(w, f.archives[int64(id)])
+
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		http.NotFound(w, r)
+	}
+}
+
+// writeRuns serves the runs newest first, two per page when a page
+// is requested. It ignores the filters like a lenient server would
+func (f *fakeGithub) writeRuns(w http.ResponseWriter, r *http.Request) {
+	runs := append([]GithubWorkflowRun{}, f.runs...)
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ID > runs[j].ID })
+	total := len(runs)
+
+	var page int
+	if _, err := fmt.Sscan(r.URL.Query().Get("page"), &page); err == nil {
+		start, end := 2*(page-1), 2*page
+		if start > len(runs) {
+			start = len(runs)
+		}
+		if end > len(runs) {
+			end = len(runs)
+		}
+		runs = runs[start:end]
+	}
+	json.NewEncoder(w).Encode(GithubWorkflowRuns{TotalCount: total, WorkflowRuns: runs})
+}
+
+// scan reports whether s matches format entirely
+func scan(s, format string, id *int) bool {
+	n, err := fmt.Sscanf(s, format, id)
+	return err == nil && n == 1 && fmt.Sprintf(format, *id) == s
+}
+
+// dispatchedRun is a run of release.yml on main triggered by actor
+func dispatchedRun(id int64, actor string) GithubWorkflowRun {
+	run := GithubWorkflowRun{
+		ID:         id,
+		RunNumber:  int(id),
+		Path:       ".github/workflows/release.yml",
+		Event:      "workflow_dispatch",
+		Status:     "queued",
+		HeadBranch: "main",
+	}
+	if actor != "" {
+		run.Actor = &GithubActor{Login: actor}
+	}
+	return run
+}
+
+func TestStartBuild(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      string
+		existing  []GithubWorkflowRun
+		appear    map[int][]GithubWorkflowRun
+		wantID    string
+		wantPolls int
+	}{
+		{
+			name:      "run appears after polls",
+			user:      "alice",
+			existing:  []GithubWorkflowRun{dispatchedRun(1, "alice")},
+			appear:    map[int][]GithubWorkflowRun{3: {dispatchedRun(10, "alice")}},
+			wantID:    "octo/app#10",
+			wantPolls: 3,
+		},
+		{
+			name: "concurrent dispatch of another user",
+			user: "alice",
+			appear: map[int][]GithubWorkflowRun{
+				1: {dispatchedRun(9, "bob")},
+				2: {dispatchedRun(10, "alice")},
+			},
+			wantID:    "octo/app#10",
+			wantPolls: 2,
+		},
+		{
+			name:      "later dispatch of the same user",
+			user:      "alice",
+			appear:    map[int][]GithubWorkflowRun{1: {dispatchedRun(11, "alice"), dispatchedRun(10, "alice")}},
+			wantID:    "octo/app#10",
+			wantPolls: 1,
+		},
+		{
+			name:      "token of a github app",
+			existing:  []GithubWorkflowRun{dispatchedRun(1, "app[bot]")},
+			appear:    map[int][]GithubWorkflowRun{2: {dispatchedRun(10, "app[bot]")}},
+			wantID:    "octo/app#10",
+			wantPolls: 2,
+		},
+	}
+
+	for _, test := range tests {
+		f := newFakeGithub(t)
+		f.user = test.user
+		f.runs = test.existing
+		f.appear = test.appear
+		client, server := f.client()
+
+		build, err := client.StartBuild(context.Background(), buildserver.TriggerRequest{
+			Pipeline: "octo/app/release.yml",
+			Params:   map[string]string{"target": "staging"},
+		})
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if build.ID != test.wantID || build.Pipeline != "octo/app/release.yml" || build.State != buildserver.StateQueued || build.Params["target"] != "staging" {
+			t.Errorf("%s: got build %+v, want %s", test.name, build, test.wantID)
+		}
+		if f.listings != test.wantPolls {
+			t.Errorf("%s: got %d polls, want %d", test.name, f.listings, test.wantPolls)
+		}
+		if len(f.dispatches) != 1 || f.dispatches[0].Ref != "main" || f.dispatches[0].Inputs["target"] != "staging" {
+			t.Errorf("%s: got dispatches %+v, want one on the default branch", test.name, f.dispatches)
+		}
+		if f.query.Get("event") != "workflow_dispatch" || f.query.Get("branch") != "main" || f.query.Get("actor") != test.user {
+			t.Errorf("%s: got query %v, want the dispatched runs of the user on main", test.name, f.query)
+		}
+	}
+}
+
+func TestStartBuildTimeout(t *testing.T) {
+	f := newFakeGithub(t)
+	f.runs = []GithubWorkflowRun{dispatchedRun(1, "alice")}
+	f.appear[1] = []GithubWorkflowRun{dispatchedRun(9, "bob")}
+	client, server := f.client()
+	defer server.Close()
+	client.dispatchTimeout = 50 * time.Millisecond
+
+	_, err := client.StartBuild(context.Background(), buildserver.TriggerRequest{Pipeline: "octo/app/release.yml", Branch: "dev"})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "no run of workflow release.yml appeared for the dispatch on dev") {
+		t.Errorf("got error %v, want a timeout", err)
+	}
+	if f.listings < 2 {
+		t.Errorf("got %d polls, want several", f.listings)
+	}
+}
+
+func TestStartBuildErrors(t *testing.T) {
+	client := NewClient(time.Second, time.Second, time.Second, "http://github.invalid", "token", false)
+
+	for _, request := range []buildserver.TriggerRequest{
+		{Pipeline: "octo/app/release.yml", Revision: "abc"},
+		{Pipeline: "octo/app/release.yml", ArtifactsFrom: map[string]string{"octo/lib/build.yml": "octo/lib#1"}},
+	} {
+		if _, err := client.StartBuild(context.Background(), request); !errors.Is(err, buildserver.ErrNotSupported) {
+			t.Errorf("StartBuild(%+v) returned %v, want ErrNotSupported", request, err)
+		}
+	}
+	for _, pipeline := range []string{"octo/app", "octo", ""} {
+		if _, err := client.StartBuild(context.Background(), buildserver.TriggerRequest{Pipeline: pipeline}); err == nil {
+			t.Errorf("expected an error for pipeline %q", pipeline)
+		}
+	}
+}
+
+func TestToBuild(t *testing.T) {
+	tests := []struct {
+		status, conclusion string
+		state              buildserver.BuildState
+		want               buildserver.BuildStatus
+		statusText         string
+	}{
+		{"requested", "", buildserver.StateQueued, buildserver.StatusUnknown, "requested"},
+		{"queued", "", buildserver.StateQueued, buildserver.StatusUnknown, "queued"},
+		{"waiting", "", buildserver.StateQueued, buildserver.StatusUnknown, "waiting"},
+		{"pending", "", buildserver.StateQueued, buildserver.StatusUnknown, "pending"},
+		{"in_progress", "", buildserver.StateRunning, buildserver.StatusUnknown, "in_progress"},
+		{"completed", "success", buildserver.StateFinished, buildserver.StatusSuccess, "success"},
+		{"completed", "failure", buildserver.StateFinished, buildserver.StatusFailure, "failure"},
+		{"completed", "timed_out", buildserver.StateFinished, buildserver.StatusFailure, "timed_out"},
+		{"completed", "startup_failure", buildserver.StateFinished, buildserver.StatusFailure, "startup_failure"},
+		{"completed", "cancelled", buildserver.StateFinished, buildserver.StatusCancelled, "cancelled"},
+		{"completed", "skipped", buildserver.StateFinished, buildserver.StatusUnknown, "skipped"},
+		{"completed", "neutral", buildserver.StateFinished, buildserver.StatusUnknown, "neutral"},
+	}
+
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	started := created.Add(time.Minute)
+	updated := created.Add(time.Hour)
+	for _, test := range tests {
+		build := toBuild("octo/app", GithubWorkflowRun{
+			ID:           7,
+			RunNumber:    3,
+			Path:         ".github/workflows/release.yml",
+			Status:       test.status,
+			Conclusion:   test.conclusion,
+			HeadBranch:   "main",
+			HeadSHA:      "abc",
+			CreatedAt:    &created,
+			RunStartedAt: &started,
+			UpdatedAt:    &updated,
+		})
+		name := test.status + " " + test.conclusion
+		if build.State != test.state || build.Status != test.want || build.StatusText != test.statusText {
+			t.Errorf("%s: got %s/%s %q, want %s/%s %q", name, build.State, build.Status, build.StatusText, test.state, test.want, test.statusText)
+		}
+		if build.ID != "octo/app#7" || build.Pipeline != "octo/app/release.yml" || build.Number != "3" || build.Revision != "abc" || !build.QueuedAt.Equal(created) {
+			t.Errorf("%s: got build %+v", name, build)
+		}
+		if build.StartedAt.IsZero() != (test.state == buildserver.StateQueued) || build.FinishedAt.IsZero() != (test.state != buildserver.StateFinished) {
+			t.Errorf("%s: got started %v and finished %v", name, build.StartedAt, build.FinishedAt)
+		}
+	}
+}
+
+func TestFailedJobs(t *testing.T) {
+	f := newFakeGithub(t)
+	f.runs = []GithubWorkflowRun{{ID: 5, Status: "completed", Conclusion: "failure"}}
+	f.jobs = []GithubJob{
+		{ID: 1, Name: "build", Status: "completed", Conclusion: "success"},
+		{ID: 2, Name: "test", Status: "completed", Conclusion: "failure"},
+		{ID: 3, Name: "lint", Status: "completed", Conclusion: "timed_out"},
+		{ID: 4, Name: "deploy", Status: "completed", Conclusion: "skipped"},
+	}
+	client, server := f.client()
+	defer server.Close()
+
+	build, err := client.GetBuild(context.Background(), "octo/app#5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if build.Status != buildserver.StatusFailure || build.StatusText != "failed jobs: test, lint" {
+		t.Errorf("got build %+v, want the failed jobs in the status text", build)
+	}
+	if f.query.Get("filter") != "latest" {
+		t.Errorf("got query %v, want the jobs of the latest attempt", f.query)
+	}
+}
+
+func TestCancelAndStop(t *testing.T) {
+	f := newFakeGithub(t)
+	client, server := f.client()
+	defer server.Close()
+	ctx := context.Background()
+
+	if err := client.CancelQueuedBuild(ctx, "octo/app#1", "not needed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.StopBuild(ctx, "octo/app#2", "not needed"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "POST /actions/runs/1/cancel,POST /actions/runs/2/cancel"
+	if got := strings.Join(f.requests, ","); got != want {
+		t.Errorf("got requests %s, want %s", got, want)
+	}
+
+	if err := client.StopBuild(ctx, "octo/app", ""); err == nil {
+		t.Error("expected an error for an invalid build id")
+	}
+}
+
+func TestListBuilds(t *testing.T) {
+	f := newFakeGithub(t)
+	f.runs = []GithubWorkflowRun{
+		{ID: 3, Status: "in_progress"},
+		{ID: 2, Status: "completed", Conclusion: "failure"},
+		{ID: 1, Status: "completed", Conclusion: "success"},
+	}
+	client, server := f.client()
+	defer server.Close()
+	ctx := context.Background()
+
+	builds, err := client.ListBuilds(ctx, buildserver.Query{Pipeline: "octo/app", Branch: "main", User: "alice", Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 3 || builds[0].ID != "octo/app#3" || builds[2].ID != "octo/app#1" {
+		t.Errorf("got builds %+v, want both pages", builds)
+	}
+	if f.query.Get("branch") != "main" || f.query.Get("actor") != "alice" || f.query.Get("page") != "2" {
+		t.Errorf("got query %v, want the branch and user as filters", f.query)
+	}
+
+	// The fake ignores the status filter, the client filters the runs it returns
+	builds, err = client.ListBuilds(ctx, buildserver.Query{Pipeline: "octo/app", Status: buildserver.StatusFailure})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 1 || builds[0].ID != "octo/app#2" || f.query.Get("status") != "failure" {
+		t.Errorf("got builds %+v and query %v, want the failed run", builds, f.query)
+	}
+
+	if _, err := client.ListBuilds(ctx, buildserver.Query{Pipeline: "octo/app", State: buildserver.StateRunning}); err != nil {
+		t.Fatal(err)
+	}
+	if f.query.Get("status") != "in_progress" {
+		t.Errorf("got query %v, want the running runs", f.query)
+	}
+
+	f.requests = nil
+	builds, err = client.ListBuilds(ctx, buildserver.Query{Pipeline: "octo/app", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 1 || len(f.requests) != 1 {
+		t.Errorf("got %d builds with %d requests, want 1 and 1", len(builds), len(f.requests))
+	}
+}
+
+// TestGetBuildLog checks the order and the paths of the job logs, their
+// concatenation is covered by the httpapi package
+func TestGetBuildLog(t *testing.T) {
+	f := newFakeGithub(t)
+	f.jobs = []GithubJob{
+		{ID: 2, Name: "test", Status: "completed"},
+		{ID: 1, Name: "build", Status: "completed"},
+	}
+	f.logs[1] = "compiling\n"
+	f.logs[2] = "FAIL: TestX\n"
+	client, server := f.client()
+	defer server.Close()
+
+	buildLog, err := client.GetBuildLog(context.Background(), "octo/app#1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(buildLog)
+	buildLog.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := strings.Index(string(content), "compiling"); i < 0 || i > strings.Index(string(content), "FAIL: TestX") {
+		t.Errorf("got log %q, want the jobs in the order they were started", content)
+	}
+
+	logs := []string{}
+	for _, request := range f.requests {
+		if strings.HasSuffix(request, "/logs") {
+			logs = append(logs, request)
+		}
+	}
+	if want := "GET /actions/jobs/1/logs,GET /actions/jobs/2/logs"; strings.Join(logs, ",") != want {
+		t.Errorf("got log requests %v, want %s", logs, want)
+	}
+}
+
+func TestArtifacts(t *testing.T) {
+	f := newFakeGithub(t)
+	f.artifacts = []GithubArtifact{
+		{ID: 1, Name: "binaries", SizeInBytes: 6},
+		{ID: 2, Name: "coverage", SizeInBytes: 100, Expired: true},
+	}
+	f.archives[1] = "binary"
+	client, server := f.client()
+	defer server.Close()
+	ctx := context.Background()
+
+	artifacts, err := client.ListArtifacts(ctx, "octo/app#1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 1 || artifacts[0] != (buildserver.Artifact{Path: "binaries.zip", Size: 6}) {
+		t.Errorf("got artifacts %+v, want the artifacts that have not expired", artifacts)
+	}
+
+	artifact, err := client.GetArtifact(ctx, "octo/app#1", "binaries.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := ioutil.ReadAll(artifact)
+	artifact.Close()
+	if string(content) != "binary" {
+		t.Errorf("got %q, want the archive", content)
+	}
+
+	if _, err := client.GetArtifact(ctx, "octo/app#1", "coverage.zip"); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("got error %v, want the artifact to have expired", err)
+	}
+	if _, err := client.GetArtifact(ctx, "octo/app#1", "docs.zip"); err == nil {
+		t.Error("expected an error for an unknown artifact")
+	}
+}
@@ -0,0 +1,79 @@
+package githubactions
+
+import "time"
+
+// GithubDispatchPayload is the body of a workflow_dispatch request
+type GithubDispatchPayload struct {
+	Ref    string            `json:"ref"`
+	Inputs map[string]string `json:"inputs,omitempty"`
+}
+
+// GithubRepository ...
+type GithubRepository struct {
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+// GithubActor ...
+type GithubActor struct {
+	Login string `json:"login"`
+}
+
+// GithubWorkflowRun ...
+type GithubWorkflowRun struct {
+	ID           int64        `json:"id"`
+	Name         string       `json:"name"`
+	DisplayTitle string       `json:"display_title,omitempty"`
+	WorkflowID   int64        `json:"workflow_id"`
+	Path         string       `json:"path"`
+	RunNumber    int          `json:"run_number"`
+	RunAttempt   int          `json:"run_attempt"`
+	Event        string       `json:"event"`
+	Status       string       `json:"status"`
+	Conclusion   string       `json:"conclusion"`
+	HeadBranch   string       `json:"head_branch"`
+	HeadSHA      string       `json:"head_sha"`
+	HTMLURL      string       `json:"html_url"`
+	Actor        *GithubActor `json:"actor,omitempty"`
+	CreatedAt    *time.Time   `json:"created_at,omitempty"`
+	UpdatedAt    *time.Time   `json:"updated_at,omitempty"`
+	RunStartedAt *time.Time   `json:"run_started_at,omitempty"`
+}
+
+// GithubWorkflowRuns is a page of workflow runs
+type GithubWorkflowRuns struct {
+	TotalCount   int                 `json:"total_count"`
+	WorkflowRuns []GithubWorkflowRun `json:"workflow_runs"`
+}
+
+// GithubJob is a job of a workflow run
+type GithubJob struct {
+	ID          int64      `json:"id"`
+	RunID       int64      `json:"run_id"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	HTMLURL     string     `json:"html_url"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// GithubJobs is a page of jobs
+type GithubJobs struct {
+	TotalCount int         `json:"total_count"`
+	Jobs       []GithubJob `json:"jobs"`
+}
+
+// GithubArtifact is a zip archive uploaded by a workflow run
+type GithubArtifact struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	SizeInBytes int64  `json:"size_in_bytes"`
+	Expired     bool   `json:"expired"`
+}
+
+// GithubArtifacts is a page of artifacts
+type GithubArtifacts struct {
+	TotalCount int              `json:"total_count"`
+	Artifacts  []GithubArtifact `json:"artifacts"`
+}
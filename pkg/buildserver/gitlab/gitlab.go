@@ -0,0 +1,417 @@
+/*
+Package gitlab implements buildserver.BuildServer for GitLab CI
+
+Pipelines are project paths such as "group/app". A build is a GitLab
+pipeline, created on the branch or tag of the trigger request with its
+params as variables. Build IDs have the form "<project>#<pipeline id>".
+
+A pipeline waiting for a manual job is reported as finished with an
+unknown status, naming the manual jobs in its status text, so that
+WaitForBuild returns instead of waiting for someone to play them. It is
+running again once a manual job is played. Scheduled pipelines, which
+wait for a delayed job, are queued.
+
+Artifacts are listed per job as "<job name>/<archive file name>", e.g.
+"build/artifacts.zip", which downloads the artifacts archive of the job.
+Other paths below a job name, e.g. "build/bin/app", are read from the
+archive of that job.
+*/
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/internal/httpapi"
+)
+
+// DefaultServerURL is gitlab.com, used when
+// the gitlab backend is opened without a host
+const DefaultServerURL = "https://gitlab.com"
+
+const defaultPageSize = 100
+
+// Client is client object to talk to gitlab
+type Client struct {
+	api *httpapi.Client
+}
+
+var _ buildserver.BuildServer = (*Client)(nil)
+
+func init() {
+	buildserver.Register("gitlab", func(ctx context.Context, config buildserver.Config) (buildserver.BuildServer, error) {
+		if config.ServerURL == "" {
+			config.ServerURL = DefaultServerURL
+		}
+		return NewClient(
+			config.RequestTimeout,
+			config.DialTimeout,
+			config.TLSHandshakeTimeout,
+			config.ServerURL,
+			config.Token,
+			config.Insecure,
+		), nil
+	})
+}
+
+// NewClient creates a client authenticating with a personal, project or group access token
+func NewClient(
+	requestTimeout, dialTimeout, tlsHandshakeTimeout time.Duration,
+	serverURL, token string,
+	insecure bool,
+) *Client {
+	return &Client{api: &httpapi.Client{
+		HTTP:    httpapi.NewHTTPClient(requestTimeout, dialTimeout, tlsHandshakeTimeout, insecure),
+		BaseURL: fmt.Sprintf("%s/api/v4", strings.TrimSuffix(serverURL, "/")),
+		Prepare: func(ctx context.Context, req *http.Request) error {
+			req.Header.Add("PRIVATE-TOKEN", token)
+			req.Header.Add("Accept", "application/json")
+			return nil
+		},
+	}}
+}
+
+// StartBuild creates a pipeline on the branch of the request with the
+// params as variables. The comment is not supported by gitlab and ignored
+func (g *Client) StartBuild(ctx context.Context, request buildserver.TriggerRequest) (buildserver.Build, error) {
+	if request.Revision != "" || len(request.ArtifactsFrom) > 0 {
+		return buildserver.Build{}, fmt.Errorf("gitlab revision and artifact dependencies: %w", buildserver.ErrNotSupported)
+	}
+	if request.Branch == "" {
+		return buildserver.Build{}, fmt.Errorf("creating a gitlab pipeline needs a branch or tag")
+	}
+
+	payload := GitlabPipelinePayload{Ref: request.Branch}
+	for k, v := range request.Params {
+		payload.Variables = append(payload.Variables, GitlabVariable{Key: k, Value: v})
+	}
+	sort.Slice(payload.Variables, func(i, j int) bool { return payload.Variables[i].Key < payload.Variables[j].Key })
+
+	var pipeline GitlabPipeline
+	if err := g.doRequest(ctx, "POST", fmt.Sprintf("%s/pipeline", projectPath(request.Pipeline)), payload, &pipeline); err != nil {
+		return buildserver.Build{}, err
+	}
+
+	build := toBuild(request.Pipeline, pipeline)
+	build.Params = request.Params
+	return build, nil
+}
+
+// GetBuild returns the current details of a pipeline. The status text
+// of a failed pipeline names its failed jobs, the one of a manual
+// pipeline its manual jobs
+func (g *Client) GetBuild(ctx context.Context, id string) (buildserver.Build, error) {
+	project, pipelineID, err := parseID(id)
+	if err != nil {
+		return buildserver.Build{}, err
+	}
+
+	var pipeline GitlabPipeline
+	if err := g.doRequest(ctx, "GET", fmt.Sprintf("%s/pipelines/%d", projectPath(project), pipelineID), nil, &pipeline); err != nil {
+		return buildserver.Build{}, err
+	}
+	build := toBuild(project, pipeline)
+
+	var variables []GitlabVariable
+	if err := g.doRequest(ctx, "GET", fmt.Sprintf("%s/pipelines/%d/variables", projectPath(project), pipelineID), nil, &variables); err != nil {
+		return buildserver.Build{}, err
+	}
+	if len(variables) > 0 {
+		build.Params = map[string]string{}
+		for _, variable := range variables {
+			build.Params[variable.Key] = variable.Value
+		}
+	}
+
+	if pipeline.Status == "failed" || pipeline.Status == "manual" {
+		jobs, err := g.ListJobs(ctx, id)
+		if err != nil {
+			return buildserver.Build{}, err
+		}
+		names := []string{}
+		for _, job := range jobs {
+			if job.Status == pipeline.Status && !job.AllowFailure {
+				names = append(names, job.Name)
+			}
+		}
+		if len(names) > 0 {
+			build.StatusText = fmt.Sprintf("%s jobs: %s", pipeline.Status, strings.Join(names, ", "))
+		}
+	}
+	return build, nil
+}
+
+// ListJobs returns the jobs of a pipeline along with their status and artifacts
+func (g *Client) ListJobs(ctx context.Context, id string) ([]GitlabJob, error) {
+	project, pipelineID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []GitlabJob{}
+	for page := "1"; page != ""; {
+		var jobsPage []GitlabJob
+		path := fmt.Sprintf("%s/pipelines/%d/jobs?include_retried=false&per_page=%d&page=%s", projectPath(project), pipelineID, defaultPageSize, page)
+		header, err := g.api.DoJSON(ctx, "GET", path, nil, &jobsPage)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, jobsPage...)
+		page = header.Get("X-Next-Page")
+	}
+	return jobs, nil
+}
+
+// ListBuilds returns the pipelines of the query project, newest first
+func (g *Client) ListBuilds(ctx context.Context, query buildserver.Query) ([]buildserver.Build, error) {
+	if query.Pipeline == "" {
+		return nil, fmt.Errorf("listing gitlab pipelines without a project: %w", buildserver.ErrNotSupported)
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+
+	filters := url.Values{}
+	filters.Set("order_by", "id")
+	filters.Set("sort", "desc")
+	filters.Set("per_page", strconv.Itoa(defaultPageSize))
+	if query.Branch != "" {
+		filters.Set("ref", query.Branch)
+	}
+	if query.User != "" {
+		filters.Set("username", query.User)
+	}
+	if !query.Since.IsZero() {
+		filters.Set("updated_after", query.Since.Format(time.RFC3339))
+	}
+	switch query.Status {
+	case buildserver.StatusSuccess:
+		filters.Set("status", "success")
+	case buildserver.StatusFailure:
+		filters.Set("status", "failed")
+	case buildserver.StatusCancelled:
+		filters.Set("status", "canceled")
+	}
+	if query.State == buildserver.StateRunning {
+		filters.Set("status", "running")
+	}
+
+	builds := []buildserver.Build{}
+	for page := "1"; page != "" && len(builds) < limit; {
+		filters.Set("page", page)
+
+		var pipelines []GitlabPipeline
+		header, err := g.api.DoJSON(ctx, "GET", fmt.Sprintf("%s/pipelines?%s", projectPath(query.Pipeline), filters.Encode()), nil, &pipelines)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pipeline := range pipelines {
+			build := toBuild(query.Pipeline, pipeline)
+			switch {
+			case query.State != "" && build.State != query.State,
+				query.Status != "" && build.Status != query.Status,
+				!query.Since.IsZero() && build.QueuedAt.Before(query.Since):
+				continue
+			}
+			builds = append(builds, build)
+			if len(builds) >= limit {
+				break
+			}
+		}
+		page = header.Get("X-Next-Page")
+	}
+	return builds, nil
+}
+
+// WaitForBuild polls a pipeline until it is finished
+func (g *Client) WaitForBuild(ctx context.Context, id string, pollInterval time.Duration) (buildserver.Build, error) {
+	return buildserver.Poll(ctx, pollInterval, func(ctx context.Context) (buildserver.Build, error) {
+		return g.GetBuild(ctx, id)
+	})
+}
+
+// CancelQueuedBuild cancels a pipeline that has not started yet. The comment
+// is not supported by gitlab and ignored
+func (g *Client) CancelQueuedBuild(ctx context.Context, id, comment string) error {
+	return g.cancel(ctx, id)
+}
+
+// StopBuild cancels the running jobs of a pipeline. The comment
+// is not supported by gitlab and ignored
+func (g *Client) StopBuild(ctx context.Context, id, comment string) error {
+	return g.cancel(ctx, id)
+}
+
+func (g *Client) cancel(ctx context.Context, id string) error {
+	project, pipelineID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return g.doRequest(ctx, "POST", fmt.Sprintf("%s/pipelines/%d/cancel", projectPath(project), pipelineID), nil, nil)
+}
+
+// GetBuildLog returns the logs of all jobs of a pipeline in creation order,
+// each preceded by a line naming the job
+func (g *Client) GetBuildLog(ctx context.Context, id string) (io.ReadCloser, error) {
+	project, _, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := g.ListJobs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Jobs are returned newest first
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+
+	logs := make([]httpapi.JobLog, 0, len(jobs))
+	for _, job := range jobs {
+		logs = append(logs, httpapi.JobLog{
+			Name:   job.Name,
+			Status: job.Status,
+			Path:   fmt.Sprintf("%s/jobs/%d/trace", projectPath(project), job.ID),
+		})
+	}
+	return g.api.ConcatLogs(ctx, logs), nil
+}
+
+// ListArtifacts returns the artifacts archives of the jobs of a pipeline
+func (g *Client) ListArtifacts(ctx context.Context, id string) ([]buildserver.Artifact, error) {
+	jobs, err := g.ListJobs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := []buildserver.Artifact{}
+	for _, job := range jobs {
+		for _, artifact := range job.Artifacts {
+			if artifact.FileType != "archive" {
+				continue
+			}
+			artifacts = append(artifacts, buildserver.Artifact{
+				Path: fmt.Sprintf("%s/%s", job.Name, artifact.Filename),
+				Size: artifact.Size,
+			})
+		}
+	}
+	return artifacts, nil
+}
+
+// GetArtifact returns the artifacts archive of a job, or a single file
+// from it, for a path listed by ListArtifacts or below its job name
+func (g *Client) GetArtifact(ctx context.Context, id, path string) (io.ReadCloser, error) {
+	project, _, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := g.ListJobs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Job names may contain '/', e.g. for parallel jobs, hence
+	// the longest job name the path starts with is used
+	var job *GitlabJob
+	for i := range jobs {
+		if strings.HasPrefix(path, jobs[i].Name+"/") && (job == nil || len(jobs[i].Name) > len(job.Name)) {
+			job = &jobs[i]
+		}
+	}
+	if job == nil {
+		return nil, fmt.Errorf("no job of build %s matches artifact %s", id, path)
+	}
+	file := strings.TrimPrefix(path, job.Name+"/")
+
+	for _, artifact := range job.Artifacts {
+		if artifact.FileType == "archive" && artifact.Filename == file {
+			return g.api.Stream(ctx, fmt.Sprintf("%s/jobs/%d/artifacts", projectPath(project), job.ID))
+		}
+	}
+	return g.api.Stream(ctx, fmt.Sprintf("%s/jobs/%d/artifacts/%s", projectPath(project), job.ID, file))
+}
+
+func (g *Client) doRequest(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	_, err := g.api.DoJSON(ctx, method, path, payload, out)
+	return err
+}
+
+// StatusError is returned when gitlab responds with a non 2xx status code
+type StatusError = httpapi.StatusError
+
+// projectPath returns the API path of a project, e.g.
+// /projects/group%2Fapp for the project group/app
+func projectPath(project string) string {
+	return fmt.Sprintf("/projects/%s", url.PathEscape(project))
+}
+
+// parseID splits a build ID into project and pipeline ID
+func parseID(id string) (string, int, error) {
+	i := strings.LastIndex(id, "#")
+	if i < 1 {
+		return "", 0, fmt.Errorf("%s is not a valid gitlab build id", id)
+	}
+
+	pipelineID, err := strconv.Atoi(id[i+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("%s is not a valid gitlab build id", id)
+	}
+	return id[:i], pipelineID, nil
+}
+
+// toBuild converts a gitlab pipeline to a neutral build
+func toBuild(project string, pipeline GitlabPipeline) buildserver.Build {
+	build := buildserver.Build{
+		ID:         fmt.Sprintf("%s#%d", project, pipeline.ID),
+		Pipeline:   project,
+		Number:     strconv.Itoa(pipeline.IID),
+		Branch:     pipeline.Ref,
+		Revision:   pipeline.SHA,
+		StatusText: pipeline.Status,
+		WebURL:     pipeline.WebURL,
+		Status:     buildserver.StatusUnknown,
+	}
+
+	switch pipeline.Status {
+	case "running":
+		build.State = buildserver.StateRunning
+	case "success":
+		build.State = buildserver.StateFinished
+		build.Status = buildserver.StatusSuccess
+	case "failed":
+		build.State = buildserver.StateFinished
+		build.Status = buildserver.StatusFailure
+	case "canceled":
+		build.State = buildserver.StateFinished
+		build.Status = buildserver.StatusCancelled
+	case "skipped", "manual":
+		// A manual pipeline waits for someone to play a manual job
+		build.State = buildserver.StateFinished
+	default:
+		// created, waiting_for_resource, preparing, pending and scheduled
+		build.State = buildserver.StateQueued
+	}
+
+	if pipeline.CreatedAt != nil {
+		build.QueuedAt = *pipeline.CreatedAt
+	}
+	if pipeline.StartedAt != nil {
+		build.StartedAt = *pipeline.StartedAt
+	}
+	if pipeline.FinishedAt != nil {
+		build.FinishedAt = *pipeline.FinishedAt
+	}
+	return build
+}
@@ -0,0 +1,392 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+)
+
+// fakeGitlab is a stand-in for the gitlab API of the project group/app
+type fakeGitlab struct {
+	t *testing.T
+
+	mu        sync.Mutex
+	requests  []string   // Method and escaped path of every request
+	query     url.Values // Query of the last request
+	payloads  []GitlabPipelinePayload
+	pipelines map[int]GitlabPipeline
+	variables map[int][]GitlabVariable
+	jobs      []GitlabJob // Jobs of every pipeline, newest first
+	traces    map[int]string
+	artifacts map[string]string // Artifact content keyed by API path
+}
+
+func newFakeGitlab(t *testing.T) *fakeGitlab {
+	return &fakeGitlab{
+		t:         t,
+		pipelines: map[int]GitlabPipeline{},
+		variables: map[int][]GitlabVariable{},
+		traces:    map[int]string{},
+		artifacts: map[string]string{},
+	}
+}
+
+func (f *fakeGitlab) client() (*Client, *httptest.Server) {
+	server := httptest.NewServer(f)
+	return NewClient(5*time.Second, 5*time.Second, 5*time.Second, server.URL, "token", false), server
+}
+
+func (f *fakeGitlab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("PRIVATE-TOKEN") != "token" {
+		http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/api/v4/projects/group%2Fapp")
+	f.requests = append(f.requests, r.Method+" "+path)
+	f.query = r.URL.Query()
+
+	var id int
+	switch {
+	case r.Method == "POST" && path == "/pipeline":
+		var payload GitlabPipelinePayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.payloads = append(f.payloads, payload)
+		id := 100 + len(f.payloads)
+		f.pipelines[id] = GitlabPipeline{ID: id, IID: len(f.payloads), Ref: payload.Ref, SHA: "abc", Status: "created"}
+		f.variables[id] = payload.Variables
+		json.NewEncoder(w).Encode(f.pipelines[id])
+
+	case path == "/pipelines":
+		if r.URL.Query().Get("page") == "2" {
+			json.NewEncoder(w).Encode([]GitlabPipeline{{ID: 1, Status: "success"}})
+			return
+		}
+		w.Header().Set("X-Next-Page", "2")
+		json.NewEncoder(w).Encode([]GitlabPipeline{{ID: 3, Status: "running"}, {ID: 2, Status: "failed"}})
+
+	case scan(path, "/pipelines/%d", &id):
+		json.NewEncoder(w).Encode(f.pipelines[id])
+
+	case scan(path, "/pipelines/%d/variables", &id):
+		variables := f.variables[id]
+		if variables == nil {
+			variables = []GitlabVariable{}
+		}
+		json.NewEncoder(w).Encode(variables)
+
+	case scan(path, "/pipelines/%d/cancel", &id):
+		pipeline := f.pipelines[id]
+		pipeline.Status = "canceled"
+		f.pipelines[id] = pipeline
+		json.NewEncoder(w).Encode(pipeline)
+
+	case scan(path, "/pipelines/%d/jobs", &id):
+		// Serve one job per page to exercise paging
+		page := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		if page < len(f.jobs) {
+			w.Header().Set("X-Next-Page", fmt.Sprint(page+1))
+		}
+		json.NewEncoder(w).Encode(f.jobs[page-1 : page])
+
+	case scan(path, "/jobs/%d/trace", &id):
+		fmt.Fprint(w, f.traces[id])
+
+	default:
+		content, ok := f.artifacts[path]
+		if !ok {
+			f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, content)
+	}
+}
+
+// scan reports whether s matches format entirely
+func scan(s, format string, id *int) bool {
+	n, err := fmt.Sscanf(s, format, id)
+	return err == nil && n == 1 && fmt.Sprintf(format, *id) == s
+}
+
+func TestStartBuild(t *testing.T) {
+	f := newFakeGitlab(t)
+	client, server := f.client()
+	defer server.Close()
+
+	build, err := client.StartBuild(context.Background(), buildserver.TriggerRequest{
+		Pipeline: "group/app",
+		Branch:   "main",
+		Params:   map[string]string{"TARGET": "staging", "DEBUG": "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if build.ID != "group/app#101" || build.State != buildserver.StateQueued || build.Params["TARGET"] != "staging" {
+		t.Errorf("got build %+v", build)
+	}
+
+	payload := f.payloads[0]
+	if payload.Ref != "main" || len(payload.Variables) != 2 ||
+		payload.Variables[0] != (GitlabVariable{Key: "DEBUG", Value: "1"}) ||
+		payload.Variables[1] != (GitlabVariable{Key: "TARGET", Value: "staging"}) {
+		t.Errorf("got payload %+v, want the params as sorted variables", payload)
+	}
+
+	got, err := client.GetBuild(context.Background(), build.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Params["DEBUG"] != "1" || got.Revision != "abc" || got.Number != "1" {
+		t.Errorf("got build %+v", got)
+	}
+}
+
+func TestStartBuildErrors(t *testing.T) {
+	client := NewClient(time.Second, time.Second, time.Second, "http://gitlab.invalid", "token", false)
+
+	for _, request := range []buildserver.TriggerRequest{
+		{Pipeline: "group/app", Branch: "main", Revision: "abc"},
+		{Pipeline: "group/app", Branch: "main", ArtifactsFrom: map[string]string{"group/lib": "group/lib#1"}},
+	} {
+		if _, err := client.StartBuild(context.Background(), request); !errors.Is(err, buildserver.ErrNotSupported) {
+			t.Errorf("StartBuild(%+v) returned %v, want ErrNotSupported", request, err)
+		}
+	}
+	if _, err := client.StartBuild(context.Background(), buildserver.TriggerRequest{Pipeline: "group/app"}); err == nil {
+		t.Error("expected an error without branch")
+	}
+	if _, err := client.ListBuilds(context.Background(), buildserver.Query{}); !errors.Is(err, buildserver.ErrNotSupported) {
+		t.Errorf("ListBuilds without project returned %v, want ErrNotSupported", err)
+	}
+}
+
+func TestToBuild(t *testing.T) {
+	tests := []struct {
+		status string
+		state  buildserver.BuildState
+		want   buildserver.BuildStatus
+	}{
+		{"created", buildserver.StateQueued, buildserver.StatusUnknown},
+		{"waiting_for_resource", buildserver.StateQueued, buildserver.StatusUnknown},
+		{"preparing", buildserver.StateQueued, buildserver.StatusUnknown},
+		{"pending", buildserver.StateQueued, buildserver.StatusUnknown},
+		{"scheduled", buildserver.StateQueued, buildserver.StatusUnknown},
+		{"manual", buildserver.StateFinished, buildserver.StatusUnknown},
+		{"running", buildserver.StateRunning, buildserver.StatusUnknown},
+		{"success", buildserver.StateFinished, buildserver.StatusSuccess},
+		{"failed", buildserver.StateFinished, buildserver.StatusFailure},
+		{"canceled", buildserver.StateFinished, buildserver.StatusCancelled},
+		{"skipped", buildserver.StateFinished, buildserver.StatusUnknown},
+	}
+
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, test := range tests {
+		build := toBuild("group/app", GitlabPipeline{ID: 7, IID: 3, Ref: "main", Status: test.status, CreatedAt: &created})
+		if build.State != test.state || build.Status != test.want {
+			t.Errorf("%s: got %s/%s, want %s/%s", test.status, build.State, build.Status, test.state, test.want)
+		}
+		if build.ID != "group/app#7" || build.Number != "3" || build.StatusText != test.status || !build.QueuedAt.Equal(created) {
+			t.Errorf("%s: got build %+v", test.status, build)
+		}
+	}
+}
+
+func TestFailedJobs(t *testing.T) {
+	f := newFakeGitlab(t)
+	f.pipelines[1] = GitlabPipeline{ID: 1, Status: "failed"}
+	f.jobs = []GitlabJob{
+		{ID: 3, Name: "deploy", Status: "skipped"},
+		{ID: 2, Name: "lint", Status: "failed", AllowFailure: true},
+		{ID: 1, Name: "test", Status: "failed"},
+	}
+	client, server := f.client()
+	defer server.Close()
+
+	build, err := client.GetBuild(context.Background(), "group/app#1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if build.Status != buildserver.StatusFailure || build.StatusText != "failed jobs: test" {
+		t.Errorf("got build %+v, want the failed job in the status text", build)
+	}
+}
+
+func TestManualJobs(t *testing.T) {
+	f := newFakeGitlab(t)
+	f.pipelines[1] = GitlabPipeline{ID: 1, Status: "manual"}
+	f.jobs = []GitlabJob{
+		{ID: 3, Name: "deploy", Status: "manual"},
+		{ID: 2, Name: "cleanup", Status: "manual", AllowFailure: true},
+		{ID: 1, Name: "build", Status: "success"},
+	}
+	client, server := f.client()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	build, err := client.WaitForBuild(ctx, "group/app#1", time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !build.Finished() || build.Status != buildserver.StatusUnknown || build.StatusText != "manual jobs: deploy" {
+		t.Errorf("got build %+v, want the blocking manual job in the status text", build)
+	}
+}
+
+func TestCancelAndStop(t *testing.T) {
+	f := newFakeGitlab(t)
+	f.pipelines[1] = GitlabPipeline{ID: 1, Status: "pending"}
+	f.pipelines[2] = GitlabPipeline{ID: 2, Status: "running"}
+	client, server := f.client()
+	defer server.Close()
+	ctx := context.Background()
+
+	if err := client.CancelQueuedBuild(ctx, "group/app#1", "not needed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.StopBuild(ctx, "group/app#2", "not needed"); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int{1, 2} {
+		if f.pipelines[id].Status != "canceled" {
+			t.Errorf("pipeline %d is %s, want canceled", id, f.pipelines[id].Status)
+		}
+	}
+
+	want := "POST /pipelines/1/cancel,POST /pipelines/2/cancel"
+	if got := strings.Join(f.requests, ","); got != want {
+		t.Errorf("got requests %s, want %s", got, want)
+	}
+
+	if err := client.StopBuild(ctx, "group/app", ""); err == nil {
+		t.Error("expected an error for an invalid build id")
+	}
+}
+
+func TestListBuilds(t *testing.T) {
+	f := newFakeGitlab(t)
+	client, server := f.client()
+	defer server.Close()
+
+	builds, err := client.ListBuilds(context.Background(), buildserver.Query{Pipeline: "group/app", Branch: "main", User: "alice", Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 3 || builds[0].ID != "group/app#3" || builds[2].ID != "group/app#1" {
+		t.Errorf("got builds %+v, want both pages", builds)
+	}
+	if f.query.Get("ref") != "main" || f.query.Get("username") != "alice" || f.query.Get("sort") != "desc" {
+		t.Errorf("got query %v, want the branch and user as filters", f.query)
+	}
+
+	f.requests = nil
+	builds, err = client.ListBuilds(context.Background(), buildserver.Query{Pipeline: "group/app", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 1 || len(f.requests) != 1 {
+		t.Errorf("got %d builds with %d requests, want 1 and 1", len(builds), len(f.requests))
+	}
+}
+
+// TestGetBuildLog checks the order and the paths of the job logs, their
+// concatenation is covered by the httpapi package
+func TestGetBuildLog(t *testing.T) {
+	f := newFakeGitlab(t)
+	f.jobs = []GitlabJob{
+		{ID: 2, Name: "test", Status: "failed"},
+		{ID: 1, Name: "build", Status: "success"},
+	}
+	f.traces[1] = "compiling\n"
+	f.traces[2] = "FAIL: TestX\n"
+	client, server := f.client()
+	defer server.Close()
+
+	buildLog, err := client.GetBuildLog(context.Background(), "group/app#1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(buildLog)
+	buildLog.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := strings.Index(string(content), "compiling"); i < 0 || i > strings.Index(string(content), "FAIL: TestX") {
+		t.Errorf("got log %q, want the jobs oldest first", content)
+	}
+
+	traces := []string{}
+	for _, request := range f.requests {
+		if strings.HasSuffix(request, "/trace") {
+			traces = append(traces, request)
+		}
+	}
+	if want := "GET /jobs/1/trace,GET /jobs/2/trace"; strings.Join(traces, ",") != want {
+		t.Errorf("got trace requests %v, want %s", traces, want)
+	}
+}
+
+func TestArtifacts(t *testing.T) {
+	f := newFakeGitlab(t)
+	f.jobs = []GitlabJob{
+		{ID: 2, Name: "build/linux", Artifacts: []GitlabArtifact{{FileType: "archive", Filename: "artifacts.zip", Size: 7}}},
+		{ID: 1, Name: "build", Artifacts: []GitlabArtifact{
+			{FileType: "archive", Filename: "artifacts.zip", Size: 3},
+			{FileType: "trace", Filename: "job.log", Size: 100},
+		}},
+	}
+	f.artifacts["/jobs/1/artifacts"] = "zip"
+	f.artifacts["/jobs/2/artifacts"] = "linux zip"
+	f.artifacts["/jobs/1/artifacts/bin/app"] = "binary"
+	client, server := f.client()
+	defer server.Close()
+	ctx := context.Background()
+
+	artifacts, err := client.ListArtifacts(ctx, "group/app#1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 2 || artifacts[0].Path != "build/linux/artifacts.zip" || artifacts[1].Path != "build/artifacts.zip" || artifacts[1].Size != 3 {
+		t.Errorf("got artifacts %+v", artifacts)
+	}
+
+	for path, want := range map[string]string{
+		"build/artifacts.zip":       "zip",
+		"build/linux/artifacts.zip": "linux zip",
+		"build/bin/app":             "binary",
+	} {
+		artifact, err := client.GetArtifact(ctx, "group/app#1", path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		content, _ := ioutil.ReadAll(artifact)
+		artifact.Close()
+		if string(content) != want {
+			t.Errorf("%s: got %q, want %q", path, content, want)
+		}
+	}
+
+	if _, err := client.GetArtifact(ctx, "group/app#1", "deploy/artifacts.zip"); err == nil {
+		t.Error("expected an error for an artifact of an unknown job")
+	}
+}
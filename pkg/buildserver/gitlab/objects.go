@@ -0,0 +1,65 @@
+package gitlab
+
+import "time"
+
+// GitlabVariable ...
+type GitlabVariable struct {
+	Key          string `json:"key"`
+	Value        string `json:"value"`
+	VariableType string `json:"variable_type,omitempty"`
+}
+
+// GitlabPipelinePayload is the body of a pipeline creation request
+type GitlabPipelinePayload struct {
+	Ref       string           `json:"ref"`
+	Variables []GitlabVariable `json:"variables,omitempty"`
+}
+
+// GitlabUser ...
+type GitlabUser struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// GitlabPipeline ...
+type GitlabPipeline struct {
+	ID         int         `json:"id"`
+	IID        int         `json:"iid"`
+	ProjectID  int         `json:"project_id"`
+	SHA        string      `json:"sha"`
+	Ref        string      `json:"ref"`
+	Status     string      `json:"status"`
+	Source     string      `json:"source,omitempty"`
+	WebURL     string      `json:"web_url"`
+	CreatedAt  *time.Time  `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time  `json:"updated_at,omitempty"`
+	StartedAt  *time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+	User       *GitlabUser `json:"user,omitempty"`
+}
+
+// GitlabArtifact is a file attached to a job. The archive holds
+// the files declared as artifacts of the job
+type GitlabArtifact struct {
+	FileType   string `json:"file_type"`
+	Size       int64  `json:"size"`
+	Filename   string `json:"filename"`
+	FileFormat string `json:"file_format,omitempty"`
+}
+
+// GitlabJob ...
+type GitlabJob struct {
+	ID            int              `json:"id"`
+	Name          string           `json:"name"`
+	Stage         string           `json:"stage"`
+	Status        string           `json:"status"`
+	Ref           string           `json:"ref"`
+	FailureReason string           `json:"failure_reason,omitempty"`
+	AllowFailure  bool             `json:"allow_failure"`
+	WebURL        string           `json:"web_url"`
+	CreatedAt     *time.Time       `json:"created_at,omitempty"`
+	StartedAt     *time.Time       `json:"started_at,omitempty"`
+	FinishedAt    *time.Time       `json:"finished_at,omitempty"`
+	Artifacts     []GitlabArtifact `json:"artifacts,omitempty"`
+}
@@ -0,0 +1,181 @@
+/*
+Package httpapi is the HTTP plumbing shared by the build server backends:
+a client making authenticated requests to the API of a build server, the
+error of responses with a non 2xx status code and the concatenation of the
+logs of the jobs of a build
+*/
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NewHTTPClient returns an http client with the timeouts of a build server
+func NewHTTPClient(requestTimeout, dialTimeout, tlsHandshakeTimeout time.Duration, insecure bool) *http.Client {
+	tr := &http.Transport{
+		Dial: (&net.Dialer{
+			Timeout: dialTimeout,
+		}).Dial,
+		Proxy:               http.ProxyFromEnvironment,
+		TLSHandshakeTimeout: tlsHandshakeTimeout,
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: insecure},
+	}
+
+	return &http.Client{
+		Timeout:   requestTimeout,
+		Transport: tr,
+	}
+}
+
+// Client makes requests to the API of a build server
+type Client struct {
+	HTTP    *http.Client
+	BaseURL string // Prefix of the request paths, e.g. https://gitlab.com/api/v4
+
+	// Prepare adds the authentication and the other headers
+	// the build server needs to a request before it is sent
+	Prepare func(ctx context.Context, req *http.Request) error
+}
+
+// Send makes a request with body sent as contentType when it is not nil.
+// Responses with a non 2xx status code are returned as *StatusError
+func (c *Client) Send(ctx context.Context, method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s%s", c.BaseURL, path), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Add("Content-Type", contentType)
+	}
+	if c.Prepare != nil {
+		if err := c.Prepare(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		message, _ := ioutil.ReadAll(resp.Body)
+		return nil, &StatusError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(message)),
+		}
+	}
+	return resp, nil
+}
+
+// DoJSON sends payload as JSON when it is not nil and decodes the JSON
+// response into out when it is not nil. The response header is returned
+// for paging
+func (c *Client) DoJSON(ctx context.Context, method, path string, payload interface{}, out interface{}) (http.Header, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(data)
+	}
+
+	resp, err := c.Send(ctx, method, path, "application/json", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return resp.Header, nil
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Header, json.Unmarshal(data, out)
+}
+
+// Stream returns the body of a GET request. The caller must close it
+func (c *Client) Stream(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := c.Send(ctx, "GET", path, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// StatusError is returned when a build server responds with a non 2xx status code
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s %s failed with status %d", e.Method, e.Path, e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s failed with status %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// ErrorStatus returns the status code of a StatusError, 0 for other errors
+func ErrorStatus(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
+
+// JobLog is the log of a job of a build
+type JobLog struct {
+	Name   string
+	Status string
+	Path   string // API path of the log
+}
+
+// ConcatLogs returns the logs of jobs one after the other, each preceded
+// by a line naming the job. A log is only requested once the previous one
+// has been read, a failed request fails the read
+func (c *Client) ConcatLogs(ctx context.Context, jobs []JobLog) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		for _, job := range jobs {
+			if _, err := fmt.Fprintf(writer, "==> %s (%s) <==\n", job.Name, job.Status); err != nil {
+				return
+			}
+
+			log, err := c.Stream(ctx, job.Path)
+			if err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+			_, err = io.Copy(writer, log)
+			log.Close()
+			if err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+		writer.Close()
+	}()
+	return reader
+}
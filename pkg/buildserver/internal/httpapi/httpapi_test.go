@@ -0,0 +1,150 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client of a stand-in authenticating with a token
+func newTestClient(handler http.HandlerFunc) (*Client, *httptest.Server) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}))
+	client := &Client{
+		HTTP:    NewHTTPClient(5*time.Second, 5*time.Second, 5*time.Second, false),
+		BaseURL: server.URL + "/api",
+		Prepare: func(ctx context.Context, req *http.Request) error {
+			req.Header.Add("Authorization", "Bearer token")
+			return nil
+		},
+	}
+	return client, server
+}
+
+func TestDoJSON(t *testing.T) {
+	var method, path, contentType string
+	var payload map[string]string
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		method, path, contentType = r.Method, r.URL.Path, r.Header.Get("Content-Type")
+		payload = nil
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.Header().Set("X-Next-Page", "2")
+		fmt.Fprint(w, `{"id":7}`)
+	})
+	defer server.Close()
+	ctx := context.Background()
+
+	var out struct{ ID int }
+	header, err := client.DoJSON(ctx, "POST", "/builds", map[string]string{"ref": "main"}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method != "POST" || path != "/api/builds" || contentType != "application/json" || payload["ref"] != "main" {
+		t.Errorf("got %s %s as %q with payload %v", method, path, contentType, payload)
+	}
+	if out.ID != 7 || header.Get("X-Next-Page") != "2" {
+		t.Errorf("got %+v and header %v", out, header)
+	}
+
+	if _, err := client.DoJSON(ctx, "GET", "/builds/7", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "" || payload != nil {
+		t.Errorf("got content type %q and payload %v, want none", contentType, payload)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/missing":
+			http.Error(w, "  build not found\n", http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusConflict)
+		}
+	})
+	defer server.Close()
+	ctx := context.Background()
+
+	_, err := client.DoJSON(ctx, "GET", "/missing", nil, nil)
+	if err == nil || err.Error() != "GET /missing failed with status 404: build not found" {
+		t.Errorf("got error %v", err)
+	}
+	if status := ErrorStatus(fmt.Errorf("getting build: %w", err)); status != http.StatusNotFound {
+		t.Errorf("got status %d of the wrapped error, want 404", status)
+	}
+
+	_, err = client.Stream(ctx, "/conflict")
+	if err == nil || err.Error() != "GET /conflict failed with status 409" {
+		t.Errorf("got error %v, want the status without a message", err)
+	}
+
+	if status := ErrorStatus(errors.New("connection refused")); status != 0 {
+		t.Errorf("got status %d for another error, want 0", status)
+	}
+
+	client.Prepare = func(ctx context.Context, req *http.Request) error { return errors.New("no crumb") }
+	if _, err := client.Send(ctx, "POST", "/builds", "", nil); err == nil || err.Error() != "no crumb" {
+		t.Errorf("got error %v, want the error of Prepare", err)
+	}
+}
+
+func TestConcatLogs(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/jobs/1/log":
+			fmt.Fprint(w, "compiling\n")
+		case "/api/jobs/2/log":
+			fmt.Fprint(w, "FAIL: TestX\n")
+		default:
+			http.Error(w, "log expired", http.StatusGone)
+		}
+	})
+	defer server.Close()
+	ctx := context.Background()
+
+	buildLog := client.ConcatLogs(ctx, []JobLog{
+		{Name: "build", Status: "success", Path: "/jobs/1/log"},
+		{Name: "test", Status: "failed", Path: "/jobs/2/log"},
+	})
+	content, err := ioutil.ReadAll(buildLog)
+	buildLog.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "==> build (success) <==\ncompiling\n==> test (failed) <==\nFAIL: TestX\n"
+	if string(content) != want {
+		t.Errorf("got log %q, want %q", content, want)
+	}
+
+	buildLog = client.ConcatLogs(ctx, []JobLog{
+		{Name: "build", Status: "success", Path: "/jobs/1/log"},
+		{Name: "old", Status: "success", Path: "/jobs/3/log"},
+	})
+	content, err = ioutil.ReadAll(buildLog)
+	buildLog.Close()
+	if err == nil || !strings.Contains(err.Error(), "log expired") {
+		t.Errorf("got error %v, want the error of the missing log", err)
+	}
+	if !strings.HasSuffix(string(content), "==> old (success) <==\n") {
+		t.Errorf("got log %q, want the logs up to the failed job", content)
+	}
+
+	// Closing the reader early stops the requests
+	buildLog = client.ConcatLogs(ctx, []JobLog{{Name: "build", Status: "success", Path: "/jobs/1/log"}})
+	if err := buildLog.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,575 @@
+/*
+Package jenkins implements buildserver.BuildServer for Jenkins
+
+Pipelines are job names, with folders separated by '/', e.g. "team/app".
+When a trigger request or query has a branch, the branch job of the
+multibranch pipeline is used, e.g. "team/app" on branch "feature/x" is the
+job "team/app/feature%2Fx".
+
+Build IDs have the form "<job>#<number>". Builds that are still waiting in
+the queue have the ID "<job>#queue-<item>" until Jenkins assigns them a
+build number. GetBuild and WaitForBuild accept both and resolve the queue
+item to its build once it has started.
+*/
+package jenkins
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/internal/httpapi"
+)
+
+const buildTree = "number,url,result,building,description,timestamp,duration,queueId," +
+	"actions[parameters[name,value],causes[shortDescription,userId],lastBuiltRevision[SHA1]],artifacts[fileName,relativePath]"
+
+const queuePrefix = "queue-"
+
+// Client is client object to talk to jenkins
+type Client struct {
+	api *httpapi.Client
+
+	// The CSRF crumb is fetched once and reused. Jenkins binds crumbs to
+	// the web session, hence the client keeps its cookies
+	crumbMu    sync.Mutex
+	crumbField string
+	crumbValue string
+	crumbValid bool
+}
+
+var _ buildserver.BuildServer = (*Client)(nil)
+
+func init() {
+	buildserver.Register("jenkins", func(ctx context.Context, config buildserver.Config) (buildserver.BuildServer, error) {
+		if config.ServerURL == "" {
+			return nil, errors.New("jenkins needs a server URL, e.g. jenkins+https://jenkins.example.com")
+		}
+		return NewClient(
+			config.RequestTimeout,
+			config.DialTimeout,
+			config.TLSHandshakeTimeout,
+			config.ServerURL,
+			config.User,
+			config.Token,
+			config.Insecure,
+		), nil
+	})
+}
+
+// NewClient creates a client authenticating as user with an API token
+func NewClient(
+	requestTimeout, dialTimeout, tlsHandshakeTimeout time.Duration,
+	serverURL, user, token string,
+	insecure bool,
+) *Client {
+	client := httpapi.NewHTTPClient(requestTimeout, dialTimeout, tlsHandshakeTimeout, insecure)
+	// cookiejar.New never fails without options
+	client.Jar, _ = cookiejar.New(nil)
+
+	j := &Client{}
+	j.api = &httpapi.Client{
+		HTTP:    client,
+		BaseURL: strings.TrimSuffix(serverURL, "/"),
+		Prepare: func(ctx context.Context, req *http.Request) error {
+			req.SetBasicAuth(user, token)
+			if req.Method != "POST" {
+				return nil
+			}
+
+			field, crumb, err := j.crumb(ctx)
+			if err != nil {
+				return err
+			}
+			if field != "" {
+				req.Header.Add(field, crumb)
+			}
+			return nil
+		},
+	}
+	return j
+}
+
+// StartBuild adds a build of a job to the jenkins queue. The params are
+// passed as build parameters. The returned build is identified by its
+// queue item until jenkins starts it
+func (j *Client) StartBuild(ctx context.Context, request buildserver.TriggerRequest) (buildserver.Build, error) {
+	if request.Revision != "" || len(request.ArtifactsFrom) > 0 {
+		return buildserver.Build{}, fmt.Errorf("jenkins revision and artifact dependencies: %w", buildserver.ErrNotSupported)
+	}
+
+	job := jobName(request.Pipeline, request.Branch)
+
+	endpoint := "build"
+	form := url.Values{}
+	if len(request.Params) > 0 {
+		endpoint = "buildWithParameters"
+		for k, v := range request.Params {
+			form.Set(k, v)
+		}
+	}
+
+	resp, err := j.do(ctx, "POST", fmt.Sprintf("%s/%s", jobPath(job), endpoint), form)
+	if err != nil {
+		return buildserver.Build{}, err
+	}
+	resp.Body.Close()
+
+	// Jenkins points to the queue item of the build in the Location header
+	location := strings.TrimSuffix(resp.Header.Get("Location"), "/")
+	item, err := strconv.Atoi(location[strings.LastIndex(location, "/")+1:])
+	if err != nil {
+		return buildserver.Build{}, fmt.Errorf("jenkins did not return the queue item of the build: %s", location)
+	}
+
+	return buildserver.Build{
+		ID:       fmt.Sprintf("%s#%s%d", job, queuePrefix, item),
+		Pipeline: job,
+		Branch:   request.Branch,
+		State:    buildserver.StateQueued,
+		Status:   buildserver.StatusUnknown,
+		Params:   request.Params,
+		QueuedAt: time.Now(),
+	}, nil
+}
+
+// GetBuild returns the current details of a build. A queue item ID is
+// resolved to the build it turned into once jenkins started it
+func (j *Client) GetBuild(ctx context.Context, id string) (buildserver.Build, error) {
+	job, number, item, err := parseID(id)
+	if err != nil {
+		return buildserver.Build{}, err
+	}
+
+	if item > 0 {
+		var queueItem JenkinsQueueItem
+		if err := j.getJSON(ctx, fmt.Sprintf("/queue/item/%d/api/json", item), &queueItem); err != nil {
+			return buildserver.Build{}, err
+		}
+
+		switch {
+		case queueItem.Cancelled:
+			return buildserver.Build{
+				ID:       id,
+				Pipeline: job,
+				State:    buildserver.StateFinished,
+				Status:   buildserver.StatusCancelled,
+			}, nil
+		case queueItem.Executable == nil:
+			return queuedBuild(job, queueItem), nil
+		}
+		number = queueItem.Executable.Number
+	}
+
+	var build JenkinsBuild
+	if err := j.getJSON(ctx, fmt.Sprintf("%s/%d/api/json?tree=%s", jobPath(job), number, url.QueryEscape(buildTree)), &build); err != nil {
+		return buildserver.Build{}, err
+	}
+	return toBuild(job, build), nil
+}
+
+// ListBuilds returns the builds of the query pipeline, newest first.
+// Queued builds are listed from the jenkins queue
+func (j *Client) ListBuilds(ctx context.Context, query buildserver.Query) ([]buildserver.Build, error) {
+	if query.Pipeline == "" {
+		return nil, fmt.Errorf("listing jenkins builds without a pipeline: %w", buildserver.ErrNotSupported)
+	}
+	job := jobName(query.Pipeline, query.Branch)
+
+	builds := []buildserver.Build{}
+	if query.State == buildserver.StateQueued {
+		var queue JenkinsQueue
+		tree := "items[id,why,inQueueSince,task[name,url],actions[parameters[name,value],causes[userId]]]"
+		if err := j.getJSON(ctx, fmt.Sprintf("/queue/api/json?tree=%s", url.QueryEscape(tree)), &queue); err != nil {
+			return nil, err
+		}
+		for _, item := range queue.Items {
+			if !strings.HasSuffix(strings.TrimSuffix(item.Task.URL, "/"), jobPath(job)) {
+				continue
+			}
+			if query.User != "" && userOf(item.Actions) != query.User {
+				continue
+			}
+			builds = append(builds, queuedBuild(job, item))
+			if query.Limit > 0 && len(builds) >= query.Limit {
+				break
+			}
+		}
+		return builds, nil
+	}
+
+	tree := fmt.Sprintf("builds[%s]", buildTree)
+	if query.Limit > 0 && query.Status == "" && query.State == "" && query.User == "" && query.Since.IsZero() {
+		tree = fmt.Sprintf("builds[%s]{0,%d}", buildTree, query.Limit)
+	}
+
+	var jenkinsJob JenkinsJob
+	if err := j.getJSON(ctx, fmt.Sprintf("%s/api/json?tree=%s", jobPath(job), url.QueryEscape(tree)), &jenkinsJob); err != nil {
+		return nil, err
+	}
+
+	for _, jenkinsBuild := range jenkinsJob.Builds {
+		build := toBuild(job, jenkinsBuild)
+		switch {
+		case query.State != "" && build.State != query.State,
+			query.Status != "" && build.Status != query.Status,
+			query.User != "" && userOf(jenkinsBuild.Actions) != query.User,
+			!query.Since.IsZero() && build.StartedAt.Before(query.Since):
+			continue
+		}
+		build.Branch = query.Branch
+		builds = append(builds, build)
+		if query.Limit > 0 && len(builds) >= query.Limit {
+			break
+		}
+	}
+	return builds, nil
+}
+
+// WaitForBuild polls a build until it is finished
+func (j *Client) WaitForBuild(ctx context.Context, id string, pollInterval time.Duration) (buildserver.Build, error) {
+	return buildserver.Poll(ctx, pollInterval, func(ctx context.Context) (buildserver.Build, error) {
+		return j.GetBuild(ctx, id)
+	})
+}
+
+// CancelQueuedBuild removes a queue item from the jenkins queue. The
+// comment is not supported by jenkins and ignored
+func (j *Client) CancelQueuedBuild(ctx context.Context, id, comment string) error {
+	_, _, item, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	if item == 0 {
+		return fmt.Errorf("build %s is not a queued build", id)
+	}
+
+	resp, err := j.do(ctx, "POST", fmt.Sprintf("/queue/cancelItem?id=%d", item), nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// StopBuild aborts a running build. The comment is set
+// as description of the build
+func (j *Client) StopBuild(ctx context.Context, id, comment string) error {
+	build, err := j.GetBuild(ctx, id)
+	if err != nil {
+		return err
+	}
+	job, number, _, err := parseID(build.ID)
+	if err != nil {
+		return err
+	}
+	if number == 0 {
+		return fmt.Errorf("build %s has not started yet", id)
+	}
+
+	resp, err := j.do(ctx, "POST", fmt.Sprintf("%s/%d/stop", jobPath(job), number), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if comment == "" {
+		return nil
+	}
+	resp, err = j.do(ctx, "POST", fmt.Sprintf("%s/%d/submitDescription", jobPath(job), number), url.Values{"description": {comment}})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// GetBuildLog returns the console log of a build as far as it is written
+func (j *Client) GetBuildLog(ctx context.Context, id string) (io.ReadCloser, error) {
+	job, number, err := j.startedBuild(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := j.do(ctx, "GET", fmt.Sprintf("%s/%d/consoleText", jobPath(job), number), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// FollowLog streams the console log of a build to w while it is being
+// written, checking for new output every pollInterval, until the build
+// finishes
+func (j *Client) FollowLog(ctx context.Context, id string, w io.Writer, pollInterval time.Duration) error {
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	job, number, err := j.startedBuild(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	start := 0
+	for {
+		resp, err := j.do(ctx, "GET", fmt.Sprintf("%s/%d/logText/progressiveText?start=%d", jobPath(job), number, start), nil)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if size, err := strconv.Atoi(resp.Header.Get("X-Text-Size")); err == nil {
+			start = size
+		}
+		if resp.Header.Get("X-More-Data") != "true" {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// ListArtifacts returns the files archived by a build
+func (j *Client) ListArtifacts(ctx context.Context, id string) ([]buildserver.Artifact, error) {
+	job, number, err := j.startedBuild(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var build JenkinsBuild
+	tree := "artifacts[fileName,relativePath]"
+	if err := j.getJSON(ctx, fmt.Sprintf("%s/%d/api/json?tree=%s", jobPath(job), number, url.QueryEscape(tree)), &build); err != nil {
+		return nil, err
+	}
+
+	artifacts := make([]buildserver.Artifact, 0, len(build.Artifacts))
+	for _, artifact := range build.Artifacts {
+		artifacts = append(artifacts, buildserver.Artifact{Path: artifact.RelativePath})
+	}
+	return artifacts, nil
+}
+
+// GetArtifact returns the content of a file archived by a build
+func (j *Client) GetArtifact(ctx context.Context, id, path string) (io.ReadCloser, error) {
+	job, number, err := j.startedBuild(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := j.do(ctx, "GET", fmt.Sprintf("%s/%d/artifact/%s", jobPath(job), number, strings.TrimPrefix(path, "/")), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// startedBuild resolves the job and build number of a build
+// that may have been referred to by its queue item
+func (j *Client) startedBuild(ctx context.Context, id string) (string, int, error) {
+	job, number, item, err := parseID(id)
+	if err != nil || item == 0 {
+		return job, number, err
+	}
+
+	build, err := j.GetBuild(ctx, id)
+	if err != nil {
+		return "", 0, err
+	}
+	job, number, _, err = parseID(build.ID)
+	if err == nil && number == 0 {
+		err = fmt.Errorf("build %s has not started yet", id)
+	}
+	return job, number, err
+}
+
+// crumb returns the CSRF protection header required on POST requests,
+// empty when CSRF protection is disabled. The crumb is fetched on first
+// use and cached until invalidated by invalidateCrumb
+func (j *Client) crumb(ctx context.Context) (string, string, error) {
+	j.crumbMu.Lock()
+	defer j.crumbMu.Unlock()
+
+	if j.crumbValid {
+		return j.crumbField, j.crumbValue, nil
+	}
+
+	var crumb JenkinsCrumb
+	err := j.getJSON(ctx, "/crumbIssuer/api/json", &crumb)
+	switch {
+	case httpapi.ErrorStatus(err) == http.StatusNotFound:
+		crumb = JenkinsCrumb{}
+	case err != nil:
+		return "", "", err
+	}
+
+	j.crumbField, j.crumbValue, j.crumbValid = crumb.CrumbRequestField, crumb.Crumb, true
+	return j.crumbField, j.crumbValue, nil
+}
+
+// invalidateCrumb makes the next POST request fetch a new crumb
+func (j *Client) invalidateCrumb() {
+	j.crumbMu.Lock()
+	defer j.crumbMu.Unlock()
+	j.crumbValid = false
+}
+
+func (j *Client) getJSON(ctx context.Context, path string, out interface{}) error {
+	_, err := j.api.DoJSON(ctx, "GET", path, nil, out)
+	return err
+}
+
+// do makes an authenticated request to jenkins. form, when not nil, is
+// sent form encoded. POST requests carry a CSRF crumb and are sent once
+// more with a new crumb when jenkins rejects the cached one. Responses
+// with a non 2xx status code are returned as *StatusError
+func (j *Client) do(ctx context.Context, method, path string, form url.Values) (*http.Response, error) {
+	resp, err := j.send(ctx, method, path, form)
+	if method == "POST" && httpapi.ErrorStatus(err) == http.StatusForbidden {
+		// The crumb may have expired with the web session
+		j.invalidateCrumb()
+		resp, err = j.send(ctx, method, path, form)
+	}
+	return resp, err
+}
+
+func (j *Client) send(ctx context.Context, method, path string, form url.Values) (*http.Response, error) {
+	var body io.Reader
+	if form != nil {
+		body = bytes.NewBufferString(form.Encode())
+	}
+	return j.api.Send(ctx, method, path, "application/x-www-form-urlencoded", body)
+}
+
+// StatusError is returned when jenkins responds with a non 2xx status code
+type StatusError = httpapi.StatusError
+
+// jobName returns the job of a pipeline, the branch job
+// of a multibranch pipeline when branch is set
+func jobName(pipeline, branch string) string {
+	if branch == "" {
+		return pipeline
+	}
+	return fmt.Sprintf("%s/%s", pipeline, strings.Replace(branch, "/", "%2F", -1))
+}
+
+// jobPath returns the URL path of a job, e.g. /job/team/job/app
+// for the job team/app
+func jobPath(job string) string {
+	var path strings.Builder
+	for _, name := range strings.Split(job, "/") {
+		path.WriteString("/job/")
+		path.WriteString(url.PathEscape(name))
+	}
+	return path.String()
+}
+
+// parseID splits a build ID into job and either build number or queue item
+func parseID(id string) (job string, number, item int, err error) {
+	i := strings.LastIndex(id, "#")
+	if i < 1 {
+		return "", 0, 0, fmt.Errorf("%s is not a valid jenkins build id", id)
+	}
+	job, ref := id[:i], id[i+1:]
+
+	if strings.HasPrefix(ref, queuePrefix) {
+		item, err = strconv.Atoi(strings.TrimPrefix(ref, queuePrefix))
+	} else {
+		number, err = strconv.Atoi(ref)
+	}
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("%s is not a valid jenkins build id", id)
+	}
+	return job, number, item, nil
+}
+
+// userOf returns the ID of the user that triggered a build
+func userOf(actions []JenkinsAction) string {
+	for _, action := range actions {
+		for _, cause := range action.Causes {
+			if cause.UserID != "" {
+				return cause.UserID
+			}
+		}
+	}
+	return ""
+}
+
+func params(actions []JenkinsAction) map[string]string {
+	var values map[string]string
+	for _, action := range actions {
+		for _, parameter := range action.Parameters {
+			if values == nil {
+				values = map[string]string{}
+			}
+			values[parameter.Name] = fmt.Sprintf("%v", parameter.Value)
+		}
+	}
+	return values
+}
+
+func queuedBuild(job string, item JenkinsQueueItem) buildserver.Build {
+	return buildserver.Build{
+		ID:         fmt.Sprintf("%s#%s%d", job, queuePrefix, item.ID),
+		Pipeline:   job,
+		State:      buildserver.StateQueued,
+		Status:     buildserver.StatusUnknown,
+		StatusText: item.Why,
+		Params:     params(item.Actions),
+		QueuedAt:   time.Unix(0, item.InQueueSince*int64(time.Millisecond)),
+	}
+}
+
+// toBuild converts a jenkins build to a neutral build
+func toBuild(job string, jenkinsBuild JenkinsBuild) buildserver.Build {
+	build := buildserver.Build{
+		ID:         fmt.Sprintf("%s#%d", job, jenkinsBuild.Number),
+		Pipeline:   job,
+		Number:     strconv.Itoa(jenkinsBuild.Number),
+		StatusText: jenkinsBuild.Description,
+		WebURL:     jenkinsBuild.URL,
+		Params:     params(jenkinsBuild.Actions),
+		State:      buildserver.StateFinished,
+		StartedAt:  time.Unix(0, jenkinsBuild.Timestamp*int64(time.Millisecond)),
+	}
+
+	for _, action := range jenkinsBuild.Actions {
+		if action.LastBuiltRevision != nil {
+			build.Revision = action.LastBuiltRevision.SHA1
+		}
+	}
+
+	switch jenkinsBuild.Result {
+	case "SUCCESS":
+		build.Status = buildserver.StatusSuccess
+	case "FAILURE", "UNSTABLE":
+		build.Status = buildserver.StatusFailure
+	case "ABORTED":
+		build.Status = buildserver.StatusCancelled
+	default:
+		build.Status = buildserver.StatusUnknown
+	}
+
+	if jenkinsBuild.Building || jenkinsBuild.Result == "" {
+		build.State = buildserver.StateRunning
+	} else {
+		build.FinishedAt = build.StartedAt.Add(time.Duration(jenkinsBuild.Duration) * time.Millisecond)
+	}
+	return build
+}
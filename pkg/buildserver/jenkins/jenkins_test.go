@@ -0,0 +1,460 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+)
+
+// fakeJenkins is a jenkins stand-in with CSRF protection binding crumbs
+// to the session cookie, as jenkins does by default
+type fakeJenkins struct {
+	t *testing.T
+
+	mu           sync.Mutex
+	csrf         bool
+	session      int // Increased to expire the session and its crumb
+	crumbFetches int
+	posts        []string // Escaped path and query of POST requests
+	forms        []map[string]string
+	queue        map[int]JenkinsQueueItem
+	builds       map[int]JenkinsBuild // Builds of the job team/app keyed by number
+	log          []string             // Chunks served one by one by progressiveText
+}
+
+func newFakeJenkins(t *testing.T) *fakeJenkins {
+	return &fakeJenkins{
+		t:      t,
+		csrf:   true,
+		queue:  map[int]JenkinsQueueItem{},
+		builds: map[int]JenkinsBuild{},
+	}
+}
+
+// client starts the stand-in and returns a client of it
+func (f *fakeJenkins) client() (*Client, *httptest.Server) {
+	server := httptest.NewServer(f)
+	return NewClient(5*time.Second, 5*time.Second, 5*time.Second, server.URL, "user", "token", false), server
+}
+
+func (f *fakeJenkins) crumb() string {
+	return fmt.Sprintf("crumb-%d", f.session)
+}
+
+func (f *fakeJenkins) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if user, token, ok := r.BasicAuth(); !ok || user != "user" || token != "token" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	path := r.URL.EscapedPath()
+	if r.Method == "POST" {
+		if f.csrf {
+			cookie, err := r.Cookie("JSESSIONID")
+			if err != nil || cookie.Value != fmt.Sprint(f.session) || r.Header.Get("Jenkins-Crumb") != f.crumb() {
+				http.Error(w, "No valid crumb was included in the request", http.StatusForbidden)
+				return
+			}
+		}
+		r.ParseForm()
+		form := map[string]string{}
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		f.posts = append(f.posts, path+"?"+r.URL.RawQuery)
+		f.forms = append(f.forms, form)
+	}
+
+	const job = "/job/team/job/app"
+	var number int
+	switch {
+	case path == "/crumbIssuer/api/json":
+		if !f.csrf {
+			http.NotFound(w, r)
+			return
+		}
+		f.crumbFetches++
+		http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: fmt.Sprint(f.session), Path: "/"})
+		json.NewEncoder(w).Encode(JenkinsCrumb{Crumb: f.crumb(), CrumbRequestField: "Jenkins-Crumb"})
+
+	case path == job+"/build" || path == job+"/buildWithParameters":
+		id := 10 + len(f.queue)
+		item := JenkinsQueueItem{ID: id, Why: "Waiting for next available executor", InQueueSince: 1000, Task: JenkinsTask{URL: "http://jenkins" + job + "/"}}
+		for k, v := range r.PostForm {
+			item.Actions = append(item.Actions, JenkinsAction{Parameters: []JenkinsParameter{{Name: k, Value: v[0]}}})
+		}
+		f.queue[id] = item
+		w.Header().Set("Location", fmt.Sprintf("http://jenkins/queue/item/%d/", id))
+		w.WriteHeader(http.StatusCreated)
+
+	case path == "/queue/api/json":
+		queue := JenkinsQueue{Items: []JenkinsQueueItem{}}
+		for _, item := range f.queue {
+			if item.Executable == nil && !item.Cancelled {
+				queue.Items = append(queue.Items, item)
+			}
+		}
+		json.NewEncoder(w).Encode(queue)
+
+	case path == "/queue/cancelItem":
+		var id int
+		fmt.Sscan(r.URL.Query().Get("id"), &id)
+		item := f.queue[id]
+		item.Cancelled = true
+		f.queue[id] = item
+
+	case strings.HasPrefix(path, "/queue/item/"):
+		var id int
+		fmt.Sscanf(path, "/queue/item/%d/api/json", &id)
+		item, ok := f.queue[id]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(item)
+
+	case path == job+"/api/json":
+		job := JenkinsJob{Builds: []JenkinsBuild{}}
+		for number := len(f.builds); number > 0; number-- {
+			job.Builds = append(job.Builds, f.builds[number])
+		}
+		json.NewEncoder(w).Encode(job)
+
+	case sscanf(path, job+"/%d/api/json", &number):
+		build, ok := f.builds[number]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(build)
+
+	case sscanf(path, job+"/%d/stop", &number):
+		build := f.builds[number]
+		build.Building, build.Result = false, "ABORTED"
+		f.builds[number] = build
+
+	case sscanf(path, job+"/%d/submitDescription", &number):
+		build := f.builds[number]
+		build.Description = r.PostForm.Get("description")
+		f.builds[number] = build
+
+	case sscanf(path, job+"/%d/consoleText", &number):
+		fmt.Fprint(w, strings.Join(f.log, ""))
+
+	case sscanf(path, job+"/%d/logText/progressiveText", &number):
+		var start int
+		fmt.Sscan(r.URL.Query().Get("start"), &start)
+		text, size := "", 0
+		for i, chunk := range f.log {
+			if size >= start && text == "" {
+				text = chunk
+				if i < len(f.log)-1 {
+					w.Header().Set("X-More-Data", "true")
+				}
+			}
+			size += len(chunk)
+			if text != "" {
+				break
+			}
+		}
+		w.Header().Set("X-Text-Size", fmt.Sprint(start+len(text)))
+		fmt.Fprint(w, text)
+
+	case path == job+"/3/artifact/dist/app.tar.gz":
+		fmt.Fprint(w, "archive")
+
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		http.NotFound(w, r)
+	}
+}
+
+// sscanf reports whether s matches format entirely
+func sscanf(s, format string, number *int) bool {
+	n, err := fmt.Sscanf(s, format, number)
+	return err == nil && n == 1 && fmt.Sprintf(format, *number) == s
+}
+
+func TestCrumb(t *testing.T) {
+	f := newFakeJenkins(t)
+	client, server := f.client()
+	defer server.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.StartBuild(ctx, buildserver.TriggerRequest{Pipeline: "team/app"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if f.crumbFetches != 1 {
+		t.Errorf("got %d crumb fetches for 2 POST requests, want the crumb to be cached", f.crumbFetches)
+	}
+
+	// The session and the crumb bound to it expire
+	f.mu.Lock()
+	f.session++
+	f.mu.Unlock()
+	if _, err := client.StartBuild(ctx, buildserver.TriggerRequest{Pipeline: "team/app"}); err != nil {
+		t.Fatalf("expired crumb: %v", err)
+	}
+	if f.crumbFetches != 2 || len(f.posts) != 3 {
+		t.Errorf("got %d crumb fetches and %d POST requests, want 2 and 3", f.crumbFetches, len(f.posts))
+	}
+}
+
+func TestCrumbDisabled(t *testing.T) {
+	f := newFakeJenkins(t)
+	f.csrf = false
+	client, server := f.client()
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.StartBuild(context.Background(), buildserver.TriggerRequest{Pipeline: "team/app"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(f.posts) != 2 {
+		t.Errorf("got %d POST requests, want 2", len(f.posts))
+	}
+}
+
+func TestStartAndResolveQueuedBuild(t *testing.T) {
+	f := newFakeJenkins(t)
+	client, server := f.client()
+	defer server.Close()
+	ctx := context.Background()
+
+	build, err := client.StartBuild(ctx, buildserver.TriggerRequest{Pipeline: "team/app", Params: map[string]string{"TARGET": "staging"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if build.ID != "team/app#queue-10" || build.State != buildserver.StateQueued {
+		t.Fatalf("got build %+v, want queue item 10", build)
+	}
+	if f.posts[0] != "/job/team/job/app/buildWithParameters?" || f.forms[0]["TARGET"] != "staging" {
+		t.Errorf("got POST %s with %v, want buildWithParameters with TARGET", f.posts[0], f.forms[0])
+	}
+
+	queued, err := client.GetBuild(ctx, build.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queued.State != buildserver.StateQueued || queued.StatusText != "Waiting for next available executor" || queued.Params["TARGET"] != "staging" {
+		t.Errorf("got queued build %+v", queued)
+	}
+
+	// Jenkins starts the build as build 1
+	f.mu.Lock()
+	item := f.queue[10]
+	item.Executable = &JenkinsExecutable{Number: 1}
+	f.queue[10] = item
+	f.builds[1] = JenkinsBuild{Number: 1, Building: true, Timestamp: 2000}
+	f.mu.Unlock()
+
+	running, err := client.GetBuild(ctx, build.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if running.ID != "team/app#1" || running.State != buildserver.StateRunning {
+		t.Errorf("got build %+v, want running build 1", running)
+	}
+}
+
+func TestCancelAndStop(t *testing.T) {
+	f := newFakeJenkins(t)
+	f.queue[10] = JenkinsQueueItem{ID: 10}
+	f.builds[1] = JenkinsBuild{Number: 1, Building: true}
+	client, server := f.client()
+	defer server.Close()
+	ctx := context.Background()
+
+	if err := client.CancelQueuedBuild(ctx, "team/app#queue-10", ""); err != nil {
+		t.Fatal(err)
+	}
+	if build, _ := client.GetBuild(ctx, "team/app#queue-10"); build.Status != buildserver.StatusCancelled {
+		t.Errorf("got build %+v, want it cancelled", build)
+	}
+	if err := client.CancelQueuedBuild(ctx, "team/app#1", ""); err == nil {
+		t.Error("expected an error cancelling a started build")
+	}
+
+	if err := client.StopBuild(ctx, "team/app#1", "no longer needed"); err != nil {
+		t.Fatal(err)
+	}
+	build, err := client.GetBuild(ctx, "team/app#1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if build.Status != buildserver.StatusCancelled || build.StatusText != "no longer needed" {
+		t.Errorf("got build %+v, want it aborted with the comment as description", build)
+	}
+
+	want := []string{"/queue/cancelItem?id=10", "/job/team/job/app/1/stop?", "/job/team/job/app/1/submitDescription?"}
+	if strings.Join(f.posts, " ") != strings.Join(want, " ") {
+		t.Errorf("got POST requests %v, want %v", f.posts, want)
+	}
+
+	if err := client.StopBuild(ctx, "team/app#queue-11", ""); err == nil {
+		t.Error("expected an error for an unknown queue item")
+	}
+}
+
+func TestListBuilds(t *testing.T) {
+	f := newFakeJenkins(t)
+	userCause := func(user string) []JenkinsAction {
+		return []JenkinsAction{{Causes: []JenkinsCause{{UserID: user}}}}
+	}
+	f.builds[1] = JenkinsBuild{Number: 1, Result: "SUCCESS", Timestamp: 1000, Actions: userCause("alice")}
+	f.builds[2] = JenkinsBuild{Number: 2, Result: "FAILURE", Timestamp: 2000, Actions: userCause("bob")}
+	f.builds[3] = JenkinsBuild{Number: 3, Result: "SUCCESS", Timestamp: 3000, Actions: userCause("bob")}
+	f.builds[4] = JenkinsBuild{Number: 4, Building: true, Timestamp: 4000, Actions: userCause("alice")}
+	f.queue[10] = JenkinsQueueItem{ID: 10, Task: JenkinsTask{URL: "http://jenkins/job/team/job/app/"}, Actions: userCause("bob")}
+	f.queue[11] = JenkinsQueueItem{ID: 11, Task: JenkinsTask{URL: "http://jenkins/job/other/"}}
+	client, server := f.client()
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		query buildserver.Query
+		want  []string
+	}{
+		{"all", buildserver.Query{Pipeline: "team/app"}, []string{"team/app#4", "team/app#3", "team/app#2", "team/app#1"}},
+		{"limit", buildserver.Query{Pipeline: "team/app", Limit: 2}, []string{"team/app#4", "team/app#3"}},
+		{"status", buildserver.Query{Pipeline: "team/app", Status: buildserver.StatusSuccess}, []string{"team/app#3", "team/app#1"}},
+		{"running", buildserver.Query{Pipeline: "team/app", State: buildserver.StateRunning}, []string{"team/app#4"}},
+		{"user and limit", buildserver.Query{Pipeline: "team/app", User: "bob", Limit: 1}, []string{"team/app#3"}},
+		{"since", buildserver.Query{Pipeline: "team/app", Since: time.Unix(2, 500)}, []string{"team/app#4", "team/app#3"}},
+		{"queued", buildserver.Query{Pipeline: "team/app", State: buildserver.StateQueued}, []string{"team/app#queue-10"}},
+		{"queued by user", buildserver.Query{Pipeline: "team/app", State: buildserver.StateQueued, User: "alice"}, []string{}},
+	}
+
+	for _, test := range tests {
+		builds, err := client.ListBuilds(context.Background(), test.query)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		ids := []string{}
+		for _, build := range builds {
+			ids = append(ids, build.ID)
+		}
+		if strings.Join(ids, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s: got builds %v, want %v", test.name, ids, test.want)
+		}
+	}
+}
+
+func TestLogs(t *testing.T) {
+	f := newFakeJenkins(t)
+	f.builds[1] = JenkinsBuild{Number: 1, Building: true}
+	f.log = []string{"Started\n", "Building\n", "Finished: SUCCESS\n"}
+	client, server := f.client()
+	defer server.Close()
+	ctx := context.Background()
+
+	buildLog, err := client.GetBuildLog(ctx, "team/app#1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := ioutil.ReadAll(buildLog)
+	buildLog.Close()
+	if string(content) != "Started\nBuilding\nFinished: SUCCESS\n" {
+		t.Errorf("got log %q", content)
+	}
+
+	var followed strings.Builder
+	if err := client.FollowLog(ctx, "team/app#1", &followed, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if followed.String() != string(content) {
+		t.Errorf("got followed log %q, want %q", followed.String(), content)
+	}
+}
+
+func TestArtifacts(t *testing.T) {
+	f := newFakeJenkins(t)
+	f.builds[3] = JenkinsBuild{Number: 3, Result: "SUCCESS", Artifacts: []JenkinsArtifact{
+		{FileName: "app.tar.gz", RelativePath: "dist/app.tar.gz"},
+		{FileName: "report.xml", RelativePath: "reports/report.xml"},
+	}}
+	client, server := f.client()
+	defer server.Close()
+	ctx := context.Background()
+
+	artifacts, err := client.ListArtifacts(ctx, "team/app#3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 2 || artifacts[0].Path != "dist/app.tar.gz" || artifacts[1].Path != "reports/report.xml" {
+		t.Errorf("got artifacts %+v", artifacts)
+	}
+
+	artifact, err := client.GetArtifact(ctx, "team/app#3", "dist/app.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := ioutil.ReadAll(artifact)
+	artifact.Close()
+	if string(content) != "archive" {
+		t.Errorf("got artifact content %q", content)
+	}
+}
+
+func TestNotSupported(t *testing.T) {
+	client := NewClient(time.Second, time.Second, time.Second, "http://jenkins.invalid", "user", "token", false)
+	ctx := context.Background()
+
+	requests := []buildserver.TriggerRequest{
+		{Pipeline: "team/app", Revision: "abc"},
+		{Pipeline: "team/app", ArtifactsFrom: map[string]string{"team/lib": "team/lib#1"}},
+	}
+	for _, request := range requests {
+		if _, err := client.StartBuild(ctx, request); !errors.Is(err, buildserver.ErrNotSupported) {
+			t.Errorf("StartBuild(%+v) returned %v, want ErrNotSupported", request, err)
+		}
+	}
+
+	if _, err := client.ListBuilds(ctx, buildserver.Query{}); !errors.Is(err, buildserver.ErrNotSupported) {
+		t.Errorf("ListBuilds without pipeline returned %v, want ErrNotSupported", err)
+	}
+}
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		id           string
+		job          string
+		number, item int
+		err          bool
+	}{
+		{id: "team/app#12", job: "team/app", number: 12},
+		{id: "team/app/feature%2Fx#queue-7", job: "team/app/feature%2Fx", item: 7},
+		{id: "a#b#3", job: "a#b", number: 3},
+		{id: "#3", err: true},
+		{id: "team/app", err: true},
+		{id: "team/app#queue-", err: true},
+	}
+
+	for _, test := range tests {
+		job, number, item, err := parseID(test.id)
+		if (err != nil) != test.err || job != test.job || number != test.number || item != test.item {
+			t.Errorf("parseID(%q) = %q, %d, %d, %v", test.id, job, number, item, err)
+		}
+	}
+
+	if path := jobPath(jobName("team/app", "feature/x")); path != "/job/team/job/app/job/feature%252Fx" {
+		t.Errorf("got job path %s", path)
+	}
+}
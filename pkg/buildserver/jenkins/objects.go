@@ -0,0 +1,88 @@
+package jenkins
+
+// JenkinsParameter ...
+type JenkinsParameter struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// JenkinsCause ...
+type JenkinsCause struct {
+	ShortDescription string `json:"shortDescription,omitempty"`
+	UserID           string `json:"userId,omitempty"`
+}
+
+// JenkinsRevision ...
+type JenkinsRevision struct {
+	SHA1 string `json:"SHA1"`
+}
+
+// JenkinsAction holds the parts of build actions that are read
+// by the client. Jenkins returns one action per plugin
+type JenkinsAction struct {
+	Class             string             `json:"_class,omitempty"`
+	Parameters        []JenkinsParameter `json:"parameters,omitempty"`
+	Causes            []JenkinsCause     `json:"causes,omitempty"`
+	LastBuiltRevision *JenkinsRevision   `json:"lastBuiltRevision,omitempty"`
+}
+
+// JenkinsArtifact ...
+type JenkinsArtifact struct {
+	FileName     string `json:"fileName"`
+	RelativePath string `json:"relativePath"`
+}
+
+// JenkinsBuild ...
+type JenkinsBuild struct {
+	Number      int               `json:"number"`
+	URL         string            `json:"url"`
+	Result      string            `json:"result"` // SUCCESS UNSTABLE FAILURE NOT_BUILT ABORTED, empty while running
+	Building    bool              `json:"building"`
+	Description string            `json:"description,omitempty"`
+	Timestamp   int64             `json:"timestamp"` // Start time in milliseconds since epoch
+	Duration    int64             `json:"duration"`  // Duration in milliseconds, 0 while running
+	QueueID     int               `json:"queueId,omitempty"`
+	Actions     []JenkinsAction   `json:"actions,omitempty"`
+	Artifacts   []JenkinsArtifact `json:"artifacts,omitempty"`
+}
+
+// JenkinsJob ...
+type JenkinsJob struct {
+	Name   string         `json:"name"`
+	URL    string         `json:"url"`
+	Builds []JenkinsBuild `json:"builds"`
+}
+
+// JenkinsTask ...
+type JenkinsTask struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// JenkinsExecutable is the build a queue item turned into
+type JenkinsExecutable struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+}
+
+// JenkinsQueueItem ...
+type JenkinsQueueItem struct {
+	ID           int                `json:"id"`
+	Why          string             `json:"why,omitempty"` // Reason the item is waiting
+	InQueueSince int64              `json:"inQueueSince"`  // Time in milliseconds since epoch
+	Cancelled    bool               `json:"cancelled,omitempty"`
+	Task         JenkinsTask        `json:"task"`
+	Executable   *JenkinsExecutable `json:"executable,omitempty"`
+	Actions      []JenkinsAction    `json:"actions,omitempty"`
+}
+
+// JenkinsQueue ...
+type JenkinsQueue struct {
+	Items []JenkinsQueueItem `json:"items"`
+}
+
+// JenkinsCrumb is the CSRF protection token required on POST requests
+type JenkinsCrumb struct {
+	Crumb             string `json:"crumb"`
+	CrumbRequestField string `json:"crumbRequestField"`
+}
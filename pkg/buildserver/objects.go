@@ -0,0 +1,74 @@
+package buildserver
+
+import "time"
+
+// BuildState is the lifecycle state of a build
+type BuildState string
+
+// Build states
+const (
+	StateQueued   BuildState = "queued"
+	StateRunning  BuildState = "running"
+	StateFinished BuildState = "finished"
+)
+
+// BuildStatus is the outcome of a build. It is only
+// meaningful once the build is finished
+type BuildStatus string
+
+// Build statuses
+const (
+	StatusUnknown   BuildStatus = "unknown"
+	StatusSuccess   BuildStatus = "success"
+	StatusFailure   BuildStatus = "failure"
+	StatusCancelled BuildStatus = "cancelled"
+)
+
+// Build is a build of a pipeline on any build server
+type Build struct {
+	ID         string            `json:"id"`               // Identifies the build on its build server
+	Pipeline   string            `json:"pipeline"`         // Build configuration, job, project or workflow the build belongs to
+	Number     string            `json:"number,omitempty"` // Human readable build number
+	Branch     string            `json:"branch,omitempty"`
+	Revision   string            `json:"revision,omitempty"`
+	State      BuildState        `json:"state"`
+	Status     BuildStatus       `json:"status"`
+	StatusText string            `json:"statusText,omitempty"`
+	WebURL     string            `json:"webUrl,omitempty"`
+	Params     map[string]string `json:"params,omitempty"`
+	QueuedAt   time.Time         `json:"queuedAt,omitempty"`
+	StartedAt  time.Time         `json:"startedAt,omitempty"`
+	FinishedAt time.Time         `json:"finishedAt,omitempty"`
+}
+
+// Finished reports whether the build has reached its final state
+func (b Build) Finished() bool {
+	return b.State == StateFinished
+}
+
+// TriggerRequest describes a build to start
+type TriggerRequest struct {
+	Pipeline      string            // Build configuration, job, project or workflow to build
+	Branch        string            // Branch or ref to build
+	Revision      string            // Exact VCS revision to build, when supported by the build server
+	Comment       string            // Text comment on the build, when supported by the build server
+	Params        map[string]string // Params, variables or inputs of the build
+	ArtifactsFrom map[string]string // Builds whose artifacts the build uses keyed by pipeline, when supported by the build server
+}
+
+// Query selects builds to list
+type Query struct {
+	Pipeline string      // Builds of this pipeline
+	Branch   string      // Builds of this branch
+	User     string      // Builds triggered by this user
+	State    BuildState  // Builds in this state, any state when empty
+	Status   BuildStatus // Builds with this status, any status when empty
+	Since    time.Time   // Builds started after this time
+	Limit    int         // Maximum number of builds to return, newest first. Build server default when 0
+}
+
+// Artifact is a file published by a build
+type Artifact struct {
+	Path string `json:"path"` // Path relative to the artifacts of the build
+	Size int64  `json:"size"`
+}
@@ -0,0 +1,142 @@
+package buildserver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default timeouts of build servers opened with Open
+const (
+	DefaultRequestTimeout      = 30 * time.Second
+	DefaultDialTimeout         = 10 * time.Second
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+// Credentials authenticate against a build server. Backends that
+// authenticate with a token only ignore the user
+type Credentials struct {
+	User  string
+	Token string
+}
+
+// Config is passed to the opener of a backend
+type Config struct {
+	// ServerURL is the URL given to Open without the backend name and options,
+	// e.g. https://teamcity.example.com for teamcity+https://teamcity.example.com.
+	// It is empty when no host was given, e.g. for githubactions://
+	ServerURL string
+	Credentials
+
+	RequestTimeout      time.Duration
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+	Insecure            bool // Skip verification of the server certificate
+}
+
+// Opener creates a build server of a backend
+type Opener func(ctx context.Context, config Config) (BuildServer, error)
+
+var (
+	openersMu sync.RWMutex
+	openers   = map[string]Opener{}
+)
+
+// Register makes a backend available to Open under a name. Backends
+// register themselves when their package is imported, hence programs
+// import the backends they support, e.g.
+//
+//	import _ "github.com/raghuP9/buildserver-client/pkg/buildserver/jenkins"
+//
+// Register panics when called twice for the same name or with a nil opener
+func Register(name string, opener Opener) {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+
+	if opener == nil {
+		panic("buildserver: Register opener is nil")
+	}
+	if _, ok := openers[name]; ok {
+		panic(fmt.Sprintf("buildserver: Register called twice for backend %s", name))
+	}
+	openers[name] = opener
+}
+
+// Backends returns the sorted names of the registered backends
+func Backends() []string {
+	openersMu.RLock()
+	defer openersMu.RUnlock()
+
+	names := make([]string, 0, len(openers))
+	for name := range openers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+Open creates a build server from a URL of the form
+
+	<backend>+<scheme>://<host>[/<path>][?<options>]
+
+e.g. teamcity+https://teamcity.example.com or jenkins+http://ci.local:8080/jenkins.
+The scheme defaults to https when only the backend is given, e.g.
+gitlab://gitlab.example.com. Supported options are
+
+	timeout=30s     request timeout
+	insecure=true   skip verification of the server certificate
+*/
+func Open(ctx context.Context, rawURL string, credentials Credentials) (BuildServer, error) {
+	serverURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, scheme := serverURL.Scheme, "https"
+	if i := strings.Index(backend, "+"); i >= 0 {
+		backend, scheme = backend[:i], backend[i+1:]
+	}
+	if backend == "" {
+		return nil, fmt.Errorf("%s does not name a build server backend, e.g. teamcity+https://host", rawURL)
+	}
+
+	openersMu.RLock()
+	opener, ok := openers[backend]
+	openersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown build server backend %s, registered backends are %v", backend, Backends())
+	}
+
+	config := Config{
+		Credentials:         credentials,
+		RequestTimeout:      DefaultRequestTimeout,
+		DialTimeout:         DefaultDialTimeout,
+		TLSHandshakeTimeout: DefaultTLSHandshakeTimeout,
+	}
+
+	options := serverURL.Query()
+	if timeout := options.Get("timeout"); timeout != "" {
+		if config.RequestTimeout, err = time.ParseDuration(timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout option: %w", err)
+		}
+	}
+	if insecure := options.Get("insecure"); insecure != "" {
+		if config.Insecure, err = strconv.ParseBool(insecure); err != nil {
+			return nil, fmt.Errorf("invalid insecure option: %w", err)
+		}
+	}
+
+	if serverURL.Host != "" {
+		serverURL.Scheme = scheme
+		serverURL.RawQuery = ""
+		serverURL.Fragment = ""
+		config.ServerURL = strings.TrimSuffix(serverURL.String(), "/")
+	}
+	return opener(ctx, config)
+}
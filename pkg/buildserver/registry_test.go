@@ -0,0 +1,113 @@
+package buildserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingServer is the build server of the test backend, it
+// records the config it was opened with
+type recordingServer struct {
+	BuildServer
+	config Config
+}
+
+func init() {
+	Register("registrytest", func(ctx context.Context, config Config) (BuildServer, error) {
+		return &recordingServer{config: config}, nil
+	})
+}
+
+func TestOpen(t *testing.T) {
+	credentials := Credentials{User: "alice", Token: "secret"}
+	defaults := Config{
+		Credentials:         credentials,
+		RequestTimeout:      DefaultRequestTimeout,
+		DialTimeout:         DefaultDialTimeout,
+		TLSHandshakeTimeout: DefaultTLSHandshakeTimeout,
+	}
+
+	tests := []struct {
+		url    string
+		modify func(*Config)
+	}{
+		{
+			url:    "registrytest+http://ci.local:8080/jenkins",
+			modify: func(c *Config) { c.ServerURL = "http://ci.local:8080/jenkins" },
+		},
+		{
+			url:    "registrytest://ci.example.com",
+			modify: func(c *Config) { c.ServerURL = "https://ci.example.com" },
+		},
+		{
+			url:    "registrytest://",
+			modify: func(c *Config) {},
+		},
+		{
+			url: "registrytest+https://ci.example.com/api/?timeout=5s&insecure=true#builds",
+			modify: func(c *Config) {
+				c.ServerURL, c.RequestTimeout, c.Insecure = "https://ci.example.com/api", 5*time.Second, true
+			},
+		},
+		{
+			url:    "registrytest://?insecure=false",
+			modify: func(c *Config) {},
+		},
+	}
+
+	for _, test := range tests {
+		server, err := Open(context.Background(), test.url, credentials)
+		if err != nil {
+			t.Errorf("%s: %v", test.url, err)
+			continue
+		}
+
+		want := defaults
+		test.modify(&want)
+		if got := server.(*recordingServer).config; got != want {
+			t.Errorf("%s: got config %+v, want %+v", test.url, got, want)
+		}
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	tests := []struct {
+		url string
+		err string
+	}{
+		{"nosuchbackend+https://ci.example.com", "unknown build server backend nosuchbackend"},
+		{"https://ci.example.com", "unknown build server backend https"},
+		{"ci.example.com", "does not name a build server backend"},
+		{"registrytest://ci.example.com?timeout=soon", "invalid timeout option"},
+		{"registrytest://ci.example.com?insecure=maybe", "invalid insecure option"},
+		{"registrytest://ci.example.com/%zz", "invalid URL escape"},
+	}
+
+	for _, test := range tests {
+		_, err := Open(context.Background(), test.url, Credentials{})
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.url, err, test.err)
+		}
+	}
+}
+
+func TestBackends(t *testing.T) {
+	found := false
+	for _, name := range Backends() {
+		found = found || name == "registrytest"
+	}
+	if !found {
+		t.Errorf("got backends %v, want the test backend", Backends())
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Register to panic for a backend registered twice")
+		}
+	}()
+	Register("registrytest", func(ctx context.Context, config Config) (BuildServer, error) { return nil, nil })
+}
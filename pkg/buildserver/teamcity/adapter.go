@@ -0,0 +1,287 @@
+package teamcity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+)
+
+const adapterBuildFields = "id,buildTypeId,number,status,state,branchName,webUrl,statusText,queuedDate,startDate,finishDate," +
+	"canceledInfo(text),properties(property(name,value)),revisions(revision(version))"
+
+// Adapter exposes a teamcity client through the backend
+// neutral buildserver.BuildServer interface
+type Adapter struct {
+	client *TCClient
+}
+
+var _ buildserver.BuildServer = (*Adapter)(nil)
+
+func init() {
+	buildserver.Register("teamcity", func(ctx context.Context, config buildserver.Config) (buildserver.BuildServer, error) {
+		if config.ServerURL == "" {
+			return nil, errors.New("teamcity needs a server URL, e.g. teamcity+https://teamcity.example.com")
+		}
+		return NewAdapter(NewTeamcityClient(
+			config.RequestTimeout,
+			config.DialTimeout,
+			config.TLSHandshakeTimeout,
+			config.ServerURL,
+			config.Token,
+			config.Insecure,
+		)), nil
+	})
+}
+
+// NewAdapter wraps a teamcity client so that it implements buildserver.BuildServer
+func NewAdapter(client *TCClient) *Adapter {
+	return &Adapter{client: client}
+}
+
+// Client returns the wrapped teamcity client to access
+// teamcity specific features
+func (a *Adapter) Client() *TCClient {
+	return a.client
+}
+
+// StartBuild adds a build to the teamcity build queue. Builds listed in
+// ArtifactsFrom are added as artifact dependencies
+func (a *Adapter) StartBuild(ctx context.Context, request buildserver.TriggerRequest) (buildserver.Build, error) {
+	options := StartBuildOptions{
+		BuildTypeID: request.Pipeline,
+		Branch:      request.Branch,
+		Comment:     request.Comment,
+		Params:      request.Params,
+		Revision:    request.Revision,
+	}
+
+	if len(request.ArtifactsFrom) > 0 {
+		options.ArtifactDependencies = map[string]int{}
+		for pipeline, id := range request.ArtifactsFrom {
+			buildID, err := parseID(id)
+			if err != nil {
+				return buildserver.Build{}, err
+			}
+			options.ArtifactDependencies[pipeline] = buildID
+		}
+	}
+
+	buildDetails, err := a.client.queueBuild(ctx, options.payload())
+	if err != nil {
+		return buildserver.Build{}, err
+	}
+	return toBuild(buildDetails), nil
+}
+
+// GetBuild returns the current details of a build
+func (a *Adapter) GetBuild(ctx context.Context, id string) (buildserver.Build, error) {
+	buildID, err := parseID(id)
+	if err != nil {
+		return buildserver.Build{}, err
+	}
+
+	var buildDetails TCBuildDetails
+	err = a.client.doRequest(ctx, "GET", fmt.Sprintf("/app/rest/builds/id:%d?fields=%s", buildID, adapterBuildFields), nil, &buildDetails)
+	if err != nil {
+		return buildserver.Build{}, err
+	}
+	return toBuild(buildDetails), nil
+}
+
+// ListBuilds returns the builds matching the query, newest first. Queued
+// builds are listed from the build queue, they come first when the query
+// has no state and no status. Builds of all branches are listed when the
+// query has no branch
+func (a *Adapter) ListBuilds(ctx context.Context, query buildserver.Query) ([]buildserver.Build, error) {
+	builds := []buildserver.Build{}
+
+	if query.State == buildserver.StateQueued {
+		queued, err := a.client.ListQueuedBuilds(ctx, TCQueueQueryParams{
+			BuildTypeID: query.Pipeline,
+			Branch:      query.Branch,
+			User:        query.User,
+			Count:       uint(query.Limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, build := range queued {
+			builds = append(builds, toBuild(build.TCBuildDetails))
+		}
+		return builds, nil
+	}
+
+	limit := uint(query.Limit)
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+
+	if query.State == "" && query.Status == "" {
+		queued, err := a.client.ListQueuedBuilds(ctx, TCQueueQueryParams{
+			BuildTypeID: query.Pipeline,
+			Branch:      query.Branch,
+			User:        query.User,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// The queue is in start order, the build queued last is the newest
+		for i := len(queued) - 1; i >= 0 && uint(len(builds)) < limit; i-- {
+			builds = append(builds, toBuild(queued[i].TCBuildDetails))
+		}
+		if uint(len(builds)) == limit {
+			return builds, nil
+		}
+	}
+
+	params := TCQueryParams{
+		BuildTypeID: query.Pipeline,
+		Branch:      query.Branch,
+		AnyBranch:   true,
+		User:        query.User,
+		Running:     query.State == buildserver.StateRunning,
+		AnyRunning:  query.State == "",
+		SinceDate:   query.Since,
+		Fields:      adapterBuildFields,
+	}
+
+	switch query.Status {
+	case buildserver.StatusSuccess:
+		params.Status = "SUCCESS"
+	case buildserver.StatusFailure:
+		params.Status = "FAILURE"
+	case buildserver.StatusCancelled:
+		params.Cancelled = true
+	case "":
+		params.AnyCancelled = true
+	}
+
+	details, err := ListBuilds(ctx, a.client, params, limit-uint(len(builds)))
+	if err != nil {
+		return nil, err
+	}
+	for _, buildDetails := range details {
+		builds = append(builds, toBuild(buildDetails))
+	}
+	return builds, nil
+}
+
+// WaitForBuild polls a build until it is finished
+func (a *Adapter) WaitForBuild(ctx context.Context, id string, pollInterval time.Duration) (buildserver.Build, error) {
+	return buildserver.Poll(ctx, pollInterval, func(ctx context.Context) (buildserver.Build, error) {
+		return a.GetBuild(ctx, id)
+	})
+}
+
+// CancelQueuedBuild removes a build from the teamcity build queue
+func (a *Adapter) CancelQueuedBuild(ctx context.Context, id, comment string) error {
+	buildID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return a.client.CancelQueuedBuilds(ctx, []int{buildID}, comment)[0].Err
+}
+
+// StopBuild stops a running build
+func (a *Adapter) StopBuild(ctx context.Context, id, comment string) error {
+	buildID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	payload := TCBuildStopPayload{
+		Comment:        comment,
+		ReaddIntoQueue: "false",
+	}
+	return a.client.doRequest(ctx, "POST", fmt.Sprintf("/app/rest/builds/id:%d", buildID), payload, nil)
+}
+
+// GetBuildLog returns the full log of a build
+func (a *Adapter) GetBuildLog(ctx context.Context, id string) (io.ReadCloser, error) {
+	buildID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return a.client.GetBuildLog(ctx, buildID)
+}
+
+// ListArtifacts returns all files in the artifacts of a build
+func (a *Adapter) ListArtifacts(ctx context.Context, id string) ([]buildserver.Artifact, error) {
+	buildID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := a.client.ListArtifacts(ctx, buildID)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make([]buildserver.Artifact, 0, len(files))
+	for _, file := range files {
+		artifacts = append(artifacts, buildserver.Artifact{Path: file.FullName, Size: file.Size})
+	}
+	return artifacts, nil
+}
+
+// GetArtifact returns the content of a file in the artifacts of a build
+func (a *Adapter) GetArtifact(ctx context.Context, id, path string) (io.ReadCloser, error) {
+	buildID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return a.client.GetArtifact(ctx, buildID, path)
+}
+
+func parseID(id string) (int, error) {
+	buildID, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a valid teamcity build id", id)
+	}
+	return buildID, nil
+}
+
+// toBuild converts teamcity build details to a neutral build
+func toBuild(details TCBuildDetails) buildserver.Build {
+	build := buildserver.Build{
+		ID:         strconv.Itoa(details.ID),
+		Pipeline:   details.BuildTypeID,
+		Number:     details.Number,
+		Branch:     details.BranchName,
+		StatusText: details.StatusText,
+		WebURL:     details.WebURL,
+		State:      buildserver.BuildState(details.State),
+		Status:     buildserver.StatusUnknown,
+	}
+
+	switch details.Status {
+	case "SUCCESS":
+		build.Status = buildserver.StatusSuccess
+	case "FAILURE":
+		build.Status = buildserver.StatusFailure
+	}
+	if details.CanceledInfo != nil {
+		build.Status = buildserver.StatusCancelled
+	}
+
+	if details.Revisions != nil && len(details.Revisions.Revision) > 0 {
+		build.Revision = details.Revisions.Revision[0].Version
+	}
+
+	if len(details.Properties.Property) > 0 {
+		build.Params = map[string]string{}
+		for _, property := range details.Properties.Property {
+			build.Params[property.Name] = property.Value
+		}
+	}
+
+	build.QueuedAt, _ = ParseTime(details.QueuedDate)
+	build.StartedAt, _ = ParseTime(details.StartDate)
+	build.FinishedAt, _ = ParseTime(details.FinishDate)
+	return build
+}
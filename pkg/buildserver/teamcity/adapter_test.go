@@ -0,0 +1,256 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver"
+)
+
+func TestToBuild(t *testing.T) {
+	build := toBuild(TCBuildDetails{
+		ID:          7,
+		BuildTypeID: "P1",
+		Number:      "42",
+		BranchName:  "main",
+		State:       "finished",
+		Status:      "FAILURE",
+		StatusText:  "Tests failed: 1",
+		WebURL:      "http://teamcity/7",
+		QueuedDate:  "20200101T100000+0000",
+		StartDate:   "20200101T100500+0000",
+		FinishDate:  "20200101T101000+0000",
+		Properties:  TCBuildProperties{Property: []TCBuildProperty{{Name: "env.A", Value: "1"}}},
+		Revisions:   &TCRevisions{Revision: []TCRevision{{Version: "abc"}}},
+	})
+
+	want := buildserver.Build{
+		ID:         "7",
+		Pipeline:   "P1",
+		Number:     "42",
+		Branch:     "main",
+		Revision:   "abc",
+		Params:     map[string]string{"env.A": "1"},
+		State:      buildserver.StateFinished,
+		Status:     buildserver.StatusFailure,
+		StatusText: "Tests failed: 1",
+		WebURL:     "http://teamcity/7",
+		QueuedAt:   time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC),
+		StartedAt:  time.Date(2020, 1, 1, 10, 5, 0, 0, time.UTC),
+		FinishedAt: time.Date(2020, 1, 1, 10, 10, 0, 0, time.UTC),
+	}
+	if !build.QueuedAt.Equal(want.QueuedAt) || !build.StartedAt.Equal(want.StartedAt) || !build.FinishedAt.Equal(want.FinishedAt) {
+		t.Errorf("got times %v, %v and %v", build.QueuedAt, build.StartedAt, build.FinishedAt)
+	}
+	build.QueuedAt, build.StartedAt, build.FinishedAt = want.QueuedAt, want.StartedAt, want.FinishedAt
+	if !reflect.DeepEqual(build, want) {
+		t.Errorf("got build %+v, want %+v", build, want)
+	}
+
+	tests := []struct {
+		details TCBuildDetails
+		state   buildserver.BuildState
+		status  buildserver.BuildStatus
+	}{
+		{TCBuildDetails{State: "queued"}, buildserver.StateQueued, buildserver.StatusUnknown},
+		{TCBuildDetails{State: "running", Status: "SUCCESS"}, buildserver.StateRunning, buildserver.StatusSuccess},
+		{TCBuildDetails{State: "finished", Status: "UNKNOWN", CanceledInfo: &TCCanceledInfo{Text: "stop"}}, buildserver.StateFinished, buildserver.StatusCancelled},
+	}
+	for _, test := range tests {
+		build := toBuild(test.details)
+		if build.State != test.state || build.Status != test.status {
+			t.Errorf("%+v: got state %s and status %s, want %s and %s", test.details, build.State, build.Status, test.state, test.status)
+		}
+	}
+}
+
+func TestAdapterListBuilds(t *testing.T) {
+	var locators []string
+	queue := []TCBuildDetails{{ID: 10, State: "queued"}, {ID: 11, State: "queued"}}
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/buildQueue":
+			// The queue order is read with fields=build(id)
+			if r.URL.Query().Get("fields") != "build(id)" {
+				locators = append(locators, "queue:"+r.URL.Query().Get("locator"))
+			}
+			json.NewEncoder(w).Encode(TCBuildSnapshotDependencies{Builds: queue})
+		case "/app/rest/builds":
+			locators = append(locators, r.URL.Query().Get("locator"))
+			json.NewEncoder(w).Encode(TCBuildSnapshotDependencies{Builds: []TCBuildDetails{
+				{ID: 9, State: "running"},
+				{ID: 8, State: "finished", Status: "SUCCESS"},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+	adapter := NewAdapter(client)
+
+	tests := []struct {
+		name     string
+		query    buildserver.Query
+		locators []string
+		ids      []string
+	}{
+		{
+			name:     "any build",
+			query:    buildserver.Query{Pipeline: "P1"},
+			locators: []string{"queue:buildType:(id:P1)", "buildType:(id:" + locatorValue("P1") + "),branch:default:any,count:98,running:any,canceled:any"},
+			ids:      []string{"11", "10", "9", "8"},
+		},
+		{
+			name:     "limit reached by queued builds",
+			query:    buildserver.Query{Limit: 1},
+			locators: []string{"queue:"},
+			ids:      []string{"11"},
+		},
+		{
+			name:     "queued builds",
+			query:    buildserver.Query{State: buildserver.StateQueued, User: "jdoe"},
+			locators: []string{"queue:user:jdoe"},
+			ids:      []string{"10", "11"},
+		},
+		{
+			name:     "running builds of a branch",
+			query:    buildserver.Query{Branch: "main", State: buildserver.StateRunning},
+			locators: []string{"branch:(name:" + locatorValue("main") + "),count:100,running:true,canceled:any"},
+			ids:      []string{"9", "8"},
+		},
+		{
+			name:     "finished builds that failed",
+			query:    buildserver.Query{State: buildserver.StateFinished, Status: buildserver.StatusFailure, Limit: 5},
+			locators: []string{"branch:default:any,status:FAILURE,count:5"},
+			ids:      []string{"9", "8"},
+		},
+		{
+			name:     "cancelled builds",
+			query:    buildserver.Query{Status: buildserver.StatusCancelled, Limit: 5},
+			locators: []string{"branch:default:any,count:5,running:any,canceled:true"},
+			ids:      []string{"9", "8"},
+		},
+	}
+
+	for _, test := range tests {
+		locators = nil
+		builds, err := adapter.ListBuilds(context.Background(), test.query)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		ids := []string{}
+		for _, build := range builds {
+			ids = append(ids, build.ID)
+		}
+		if !reflect.DeepEqual(ids, test.ids) {
+			t.Errorf("%s: got builds %v, want %v", test.name, ids, test.ids)
+		}
+		if !reflect.DeepEqual(locators, test.locators) {
+			t.Errorf("%s: got locators %q, want %q", test.name, locators, test.locators)
+		}
+	}
+}
+
+func TestAdapterGetBuild(t *testing.T) {
+	var fields string
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		fields = r.URL.Query().Get("fields")
+		switch r.URL.Path {
+		case "/app/rest/builds/id:1":
+			json.NewEncoder(w).Encode(TCBuildDetails{ID: 1, State: "queued", QueuedDate: "20200101T100000+0000"})
+		case "/app/rest/builds/id:2":
+			json.NewEncoder(w).Encode(TCBuildDetails{ID: 2, State: "finished", Status: "SUCCESS"})
+		default:
+			http.Error(w, fmt.Sprintf("No build found by locator '%s'", strings.TrimPrefix(r.URL.Path, "/app/rest/builds/")), http.StatusNotFound)
+		}
+	})
+	defer server.Close()
+	adapter := NewAdapter(client)
+	ctx := context.Background()
+
+	queued, err := adapter.GetBuild(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queued.State != buildserver.StateQueued || queued.Status != buildserver.StatusUnknown || queued.QueuedAt.IsZero() || queued.Finished() {
+		t.Errorf("got build %+v, want a queued build", queued)
+	}
+	if fields != adapterBuildFields {
+		t.Errorf("got fields %q, want %q", fields, adapterBuildFields)
+	}
+
+	finished, err := adapter.WaitForBuild(ctx, "2", time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !finished.Finished() || finished.Status != buildserver.StatusSuccess {
+		t.Errorf("got build %+v, want a successful build", finished)
+	}
+
+	if _, err := adapter.GetBuild(ctx, "3"); err == nil || !strings.Contains(err.Error(), "No build found") {
+		t.Errorf("got error %v, want the error of teamcity", err)
+	}
+	if _, err := adapter.GetBuild(ctx, "abc"); err == nil || !strings.Contains(err.Error(), "not a valid teamcity build id") {
+		t.Errorf("got error %v for an invalid id", err)
+	}
+}
+
+func TestAdapterArtifactsAndLog(t *testing.T) {
+	var authorization []string
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		switch {
+		case r.URL.Path == "/app/rest/builds/id:5/artifacts/content/reports/result.xml":
+			fmt.Fprint(w, "<testsuite/>")
+		case r.URL.Path == "/downloadBuildLog.html" && r.URL.Query().Get("buildId") == "5":
+			fmt.Fprint(w, "Step 1/2\nStep 2/2\n")
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	})
+	defer server.Close()
+	adapter := NewAdapter(client)
+	ctx := context.Background()
+
+	artifact, err := adapter.GetArtifact(ctx, "5", "/reports/result.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := ioutil.ReadAll(artifact)
+	artifact.Close()
+	if string(content) != "<testsuite/>" {
+		t.Errorf("got artifact %q", content)
+	}
+
+	log, err := adapter.GetBuildLog(ctx, "5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ = ioutil.ReadAll(log)
+	log.Close()
+	if string(content) != "Step 1/2\nStep 2/2\n" {
+		t.Errorf("got log %q", content)
+	}
+
+	if _, err := adapter.GetArtifact(ctx, "5", "missing.txt"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("got error %v, want the status of a missing artifact", err)
+	}
+	if _, err := adapter.GetBuildLog(ctx, "x"); err == nil {
+		t.Error("expected an error for an invalid id")
+	}
+
+	for _, header := range authorization {
+		if header != "Bearer token" {
+			t.Errorf("got authorization %q, want the token", header)
+		}
+	}
+}
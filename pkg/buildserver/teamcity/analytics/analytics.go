@@ -0,0 +1,189 @@
+// Package analytics computes CI health numbers such as success rate,
+// queue and run times and failure streaks from the build history
+// of a pipeline
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+)
+
+// Options ...
+type Options struct {
+	BuildTypeID string    // Pipeline name (BuildConfig ID)
+	Branch      string    // Branch name, default branch when empty
+	Since       time.Time // Start of the date window
+	Until       time.Time // End of the date window, now when zero
+}
+
+// DurationStats summarises a set of durations
+type DurationStats struct {
+	Mean time.Duration
+	P50  time.Duration
+	P95  time.Duration
+}
+
+// MarshalJSON renders the durations in a human readable form such as 1m30s
+func (s DurationStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"mean": s.Mean.String(),
+		"p50":  s.P50.String(),
+		"p95":  s.P95.String(),
+	})
+}
+
+// Streak is a run of consecutive failed builds
+type Streak struct {
+	Length       int       `json:"length"`
+	FirstBuildID int       `json:"firstBuildId"`
+	LastBuildID  int       `json:"lastBuildId"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+}
+
+// Report describes the health of a pipeline over a date window
+type Report struct {
+	BuildTypeID          string        `json:"pipeline"`
+	Since                time.Time     `json:"since"`
+	Until                time.Time     `json:"until"`
+	Builds               int           `json:"builds"`
+	Successes            int           `json:"successes"`
+	Failures             int           `json:"failures"`
+	SuccessRate          float64       `json:"successRate"` // Between 0 and 1
+	QueueTime            DurationStats `json:"queueTime"`
+	RunTime              DurationStats `json:"runTime"`
+	FailureStreaks       []Streak      `json:"failureStreaks"`
+	LongestFailureStreak int           `json:"longestFailureStreak"`
+	CurrentFailureStreak int           `json:"currentFailureStreak"` // Failed builds since the last successful build
+}
+
+// Generate pages through the finished builds of a pipeline
+// in the date window and computes its report
+func Generate(ctx context.Context, lister teamcity.BuildLister, options Options) (Report, error) {
+	if options.Until.IsZero() {
+		options.Until = time.Now()
+	}
+
+	builds, err := teamcity.ListBuilds(ctx, lister, teamcity.TCQueryParams{
+		BuildTypeID: options.BuildTypeID,
+		Branch:      options.Branch,
+		SinceDate:   options.Since,
+		Fields:      "id,buildTypeId,status,state,queuedDate,startDate,finishDate",
+	}, 0)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report, err := Compute(builds, options.Since, options.Until)
+	report.BuildTypeID = options.BuildTypeID
+	return report, err
+}
+
+// Compute computes the report of the builds finished between since
+// and until. builds are expected newest first as returned by teamcity
+// and must include their queued, start and finish dates
+func Compute(builds []teamcity.TCBuildDetails, since, until time.Time) (Report, error) {
+	report := Report{
+		Since:          since,
+		Until:          until,
+		FailureStreaks: []Streak{},
+	}
+
+	var queueTimes, runTimes []time.Duration
+	var streak *Streak
+	for i := len(builds) - 1; i >= 0; i-- {
+		build := builds[i]
+		if build.FinishDate == "" {
+			continue
+		}
+
+		queued, started, finished, err := buildTimes(build)
+		if err != nil {
+			return report, err
+		}
+		if finished.Before(since) || finished.After(until) {
+			continue
+		}
+
+		report.Builds++
+		queueTimes = append(queueTimes, started.Sub(queued))
+		runTimes = append(runTimes, finished.Sub(started))
+
+		switch build.Status {
+		case "SUCCESS":
+			report.Successes++
+			streak = nil
+		case "FAILURE":
+			report.Failures++
+			if streak == nil {
+				report.FailureStreaks = append(report.FailureStreaks, Streak{FirstBuildID: build.ID, Start: started})
+				streak = &report.FailureStreaks[len(report.FailureStreaks)-1]
+			}
+			streak.Length++
+			streak.LastBuildID = build.ID
+			streak.End = finished
+		}
+	}
+
+	if report.Builds > 0 {
+		report.SuccessRate = float64(report.Successes) / float64(report.Builds)
+	}
+
+	for _, s := range report.FailureStreaks {
+		if s.Length > report.LongestFailureStreak {
+			report.LongestFailureStreak = s.Length
+		}
+	}
+	if streak != nil {
+		report.CurrentFailureStreak = streak.Length
+	}
+
+	report.QueueTime = summarise(queueTimes)
+	report.RunTime = summarise(runTimes)
+	return report, nil
+}
+
+func buildTimes(build teamcity.TCBuildDetails) (queued, started, finished time.Time, err error) {
+	if queued, err = teamcity.ParseTime(build.QueuedDate); err != nil {
+		return
+	}
+	if started, err = teamcity.ParseTime(build.StartDate); err != nil {
+		return
+	}
+	finished, err = teamcity.ParseTime(build.FinishDate)
+	return
+}
+
+func summarise(durations []time.Duration) DurationStats {
+	if len(durations) == 0 {
+		return DurationStats{}
+	}
+
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	return DurationStats{
+		Mean: total / time.Duration(len(sorted)),
+		P50:  percentile(sorted, 50),
+		P95:  percentile(sorted, 95),
+	}
+}
+
+// percentile returns the nearest rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,113 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+)
+
+var epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// build returns a finished build queued at epoch plus queuedAt
+// minutes that waited and ran for the given minutes
+func build(id int, status string, queuedAt, wait, run int) teamcity.TCBuildDetails {
+	queued := epoch.Add(time.Duration(queuedAt) * time.Minute)
+	started := queued.Add(time.Duration(wait) * time.Minute)
+	finished := started.Add(time.Duration(run) * time.Minute)
+	return teamcity.TCBuildDetails{
+		ID:         id,
+		Status:     status,
+		State:      "finished",
+		QueuedDate: queued.Format(teamcity.TimeFormat),
+		StartDate:  started.Format(teamcity.TimeFormat),
+		FinishDate: finished.Format(teamcity.TimeFormat),
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	tests := []struct {
+		durations []time.Duration
+		p         float64
+		want      time.Duration
+	}{
+		{sorted, 0, 1},
+		{sorted, 10, 1},
+		{sorted, 11, 2},
+		{sorted, 50, 5},
+		{sorted, 95, 10},
+		{sorted, 100, 10},
+		{[]time.Duration{7}, 95, 7},
+	}
+
+	for _, test := range tests {
+		if got := percentile(test.durations, test.p); got != test.want {
+			t.Errorf("percentile(%v, %v) = %v, want %v", test.durations, test.p, got, test.want)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	// Newest first as returned by teamcity
+	builds := []teamcity.TCBuildDetails{
+		build(8, "FAILURE", 70, 1, 2),
+		build(7, "FAILURE", 60, 1, 2),
+		{ID: 6, Status: "SUCCESS", State: "running", QueuedDate: epoch.Format(teamcity.TimeFormat)},
+		build(5, "SUCCESS", 40, 2, 10),
+		build(4, "FAILURE", 30, 1, 4),
+		build(3, "FAILURE", 20, 1, 4),
+		build(2, "FAILURE", 10, 1, 4),
+		build(1, "SUCCESS", 0, 3, 20),
+		build(0, "FAILURE", -60, 1, 1), // Finished before the window
+	}
+
+	report, err := Compute(builds, epoch, epoch.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Builds != 7 || report.Successes != 2 || report.Failures != 5 {
+		t.Errorf("got %d builds, %d successes and %d failures, want 7, 2 and 5", report.Builds, report.Successes, report.Failures)
+	}
+	if report.SuccessRate != 2.0/7 {
+		t.Errorf("got success rate %v, want %v", report.SuccessRate, 2.0/7)
+	}
+
+	if len(report.FailureStreaks) != 2 {
+		t.Fatalf("got streaks %+v, want 2", report.FailureStreaks)
+	}
+	if s := report.FailureStreaks[0]; s.Length != 3 || s.FirstBuildID != 2 || s.LastBuildID != 4 {
+		t.Errorf("got first streak %+v, want builds 2 to 4", s)
+	}
+	if report.LongestFailureStreak != 3 || report.CurrentFailureStreak != 2 {
+		t.Errorf("got longest streak %d and current streak %d, want 3 and 2", report.LongestFailureStreak, report.CurrentFailureStreak)
+	}
+
+	wantQueue := DurationStats{Mean: 10 * time.Minute / 7, P50: time.Minute, P95: 3 * time.Minute}
+	if report.QueueTime != wantQueue {
+		t.Errorf("got queue time %+v, want %+v", report.QueueTime, wantQueue)
+	}
+	wantRun := DurationStats{Mean: 46 * time.Minute / 7, P50: 4 * time.Minute, P95: 20 * time.Minute}
+	if report.RunTime != wantRun {
+		t.Errorf("got run time %+v, want %+v", report.RunTime, wantRun)
+	}
+}
+
+func TestComputeEmpty(t *testing.T) {
+	report, err := Compute(nil, epoch, epoch.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Builds != 0 || report.SuccessRate != 0 || report.FailureStreaks == nil {
+		t.Errorf("unexpected report of no builds %+v", report)
+	}
+}
+
+func TestComputeInvalidDate(t *testing.T) {
+	builds := []teamcity.TCBuildDetails{{ID: 1, QueuedDate: "yesterday", StartDate: "yesterday", FinishDate: "today"}}
+	if _, err := Compute(builds, epoch, epoch.Add(time.Hour)); err == nil {
+		t.Error("expected an error for unparsable dates")
+	}
+}
@@ -0,0 +1,67 @@
+package teamcity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ListArtifacts returns all files in the artifacts of a build
+// including the files of nested directories
+func (t *TCClient) ListArtifacts(ctx context.Context, id int) ([]TCArtifactFile, error) {
+	var files TCArtifactFiles
+	err := t.doRequest(
+		ctx,
+		"GET",
+		fmt.Sprintf("/app/rest/builds/id:%d/artifacts?locator=recursive:true&fields=file(name,fullName,size,modificationTime,children)", id),
+		nil,
+		&files)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := []TCArtifactFile{}
+	for _, file := range files.File {
+		if file.Children == nil {
+			artifacts = append(artifacts, file)
+		}
+	}
+	return artifacts, nil
+}
+
+// GetArtifact returns the content of a file in the artifacts of a build.
+// Unlike GetArtifactTextFile the content is streamed, the caller closes it
+func (t *TCClient) GetArtifact(ctx context.Context, id int, path string) (io.ReadCloser, error) {
+	return t.doStream(ctx, fmt.Sprintf("/app/rest/builds/id:%d/artifacts/content/%s", id, strings.TrimPrefix(path, "/")))
+}
+
+// GetBuildLog returns the full log of a build, the caller closes it
+func (t *TCClient) GetBuildLog(ctx context.Context, id int) (io.ReadCloser, error) {
+	return t.doStream(ctx, fmt.Sprintf("/downloadBuildLog.html?buildId=%d", id))
+}
+
+// doStream makes an authenticated GET request to teamcity and returns
+// the response body unread. Responses with a non 2xx status code are
+// returned as error
+func (t *TCClient) doStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s", t.serverURL, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	t.setAuthorizationHeader(req.Header)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GET %s failed with status %d: %s", path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.Body, nil
+}
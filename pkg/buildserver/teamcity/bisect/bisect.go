@@ -0,0 +1,174 @@
+// Package bisect finds the change that broke a pipeline by building
+// the changes between a good and a bad build in binary search order
+package bisect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+)
+
+// Client is the subset of the teamcity client used to bisect
+type Client interface {
+	GetBuildDetails(ctx context.Context, id int) (teamcity.TCBuildDetails, error)
+	ChangesBetween(ctx context.Context, fromBuildID, toBuildID int) ([]teamcity.TCChange, error)
+	StartBuildWithOptions(ctx context.Context, options teamcity.StartBuildOptions) (int, error)
+	WaitForBuild(ctx context.Context, id int, pollInterval time.Duration) (teamcity.TCBuildDetails, error)
+}
+
+// Options ...
+type Options struct {
+	BuildTypeID  string            // Pipeline name (BuildConfig ID), pipeline of the bad build when empty
+	Branch       string            // Branch name, branch of the bad build when empty
+	Params       map[string]string // Params added to every bisection build
+	PollInterval time.Duration     // Interval at which running builds are polled
+	OnStep       func(Step)        // Called when a bisection build finishes
+}
+
+// Step is a build of a change made while bisecting
+type Step struct {
+	Change teamcity.TCChange       `json:"change"`
+	Build  teamcity.TCBuildDetails `json:"build"`
+	Good   bool                    `json:"good"`
+}
+
+// Result is the outcome of a bisection
+type Result struct {
+	FirstBadChange teamcity.TCChange       `json:"firstBadChange"`
+	FirstBadBuild  teamcity.TCBuildDetails `json:"firstBadBuild"` // Build of the first bad change
+	LastGoodBuild  teamcity.TCBuildDetails `json:"lastGoodBuild"` // Build of the change before it
+	Steps          []Step                  `json:"steps"`
+	Candidates     int                     `json:"candidates"` // Number of changes between the good and bad builds
+}
+
+/*
+Run binary searches the changes between goodBuildID and badBuildID for the
+first change whose build fails
+
+The good build must have succeeded and the bad build must have failed.
+The changes must all come from one VCS root instance, changes of several
+roots are only ordered by their ids and can't be bisected in commit order.
+Every change that is tested is built on its exact revision and waited for.
+A build that neither succeeds nor fails, e.g. because it was cancelled,
+stops the bisection with an error
+*/
+func Run(ctx context.Context, client Client, goodBuildID, badBuildID int, options Options) (Result, error) {
+	var result Result
+
+	good, err := client.GetBuildDetails(ctx, goodBuildID)
+	if err != nil {
+		return result, err
+	}
+	if good.Status != "SUCCESS" {
+		return result, fmt.Errorf("good build %d finished with status %s, not SUCCESS", goodBuildID, good.Status)
+	}
+
+	bad, err := client.GetBuildDetails(ctx, badBuildID)
+	if err != nil {
+		return result, err
+	}
+	if bad.Status != "FAILURE" {
+		return result, fmt.Errorf("bad build %d finished with status %s, not FAILURE", badBuildID, bad.Status)
+	}
+
+	if options.BuildTypeID == "" {
+		options.BuildTypeID = bad.BuildTypeID
+	}
+	if options.Branch == "" {
+		options.Branch = bad.BranchName
+	}
+
+	// Changes are returned newest first, bisection works oldest first
+	newestFirst, err := client.ChangesBetween(ctx, goodBuildID, badBuildID)
+	if err != nil {
+		return result, err
+	}
+	if len(newestFirst) == 0 {
+		return result, errors.New("there are no changes between the good and the bad build")
+	}
+	if err := checkVcsRoot(newestFirst); err != nil {
+		return result, err
+	}
+	changes := make([]teamcity.TCChange, len(newestFirst))
+	for i, change := range newestFirst {
+		changes[len(changes)-1-i] = change
+	}
+	result.Candidates = len(changes)
+
+	// Invariant: the build of changes[lo] is good, with -1 standing for the
+	// good build, and the build of changes[hi] is bad
+	lo, hi := -1, len(changes)-1
+	builds := map[int]teamcity.TCBuildDetails{lo: good, hi: bad}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+
+		build, err := buildChange(ctx, client, changes[mid], options)
+		if err != nil {
+			return result, err
+		}
+		builds[mid] = build
+
+		step := Step{Change: changes[mid], Build: build}
+		switch build.Status {
+		case "SUCCESS":
+			step.Good = true
+			lo = mid
+		case "FAILURE":
+			hi = mid
+		default:
+			return result, fmt.Errorf("build %d of change %s finished with status %s", build.ID, changes[mid].Version, build.Status)
+		}
+
+		result.Steps = append(result.Steps, step)
+		if options.OnStep != nil {
+			options.OnStep(step)
+		}
+	}
+
+	result.FirstBadChange = changes[hi]
+	result.FirstBadBuild = builds[hi]
+	result.LastGoodBuild = builds[lo]
+	return result, nil
+}
+
+// buildChange builds the pipeline on a change and waits for the build
+func buildChange(ctx context.Context, client Client, change teamcity.TCChange, options Options) (teamcity.TCBuildDetails, error) {
+	id, err := client.StartBuildWithOptions(ctx, teamcity.StartBuildOptions{
+		BuildTypeID: options.BuildTypeID,
+		Branch:      options.Branch,
+		Comment:     fmt.Sprintf("Bisecting change %s", change.Version),
+		Params:      options.Params,
+		ChangeID:    change.ID,
+		Tags:        []string{"bisect"},
+	})
+	if err != nil {
+		return teamcity.TCBuildDetails{}, err
+	}
+
+	return client.WaitForBuild(ctx, id, options.PollInterval)
+}
+
+// checkVcsRoot returns an error if the changes belong to more than one
+// VCS root instance
+func checkVcsRoot(changes []teamcity.TCChange) error {
+	root := vcsRoot(changes[0])
+	for _, change := range changes[1:] {
+		if other := vcsRoot(change); other != root {
+			return fmt.Errorf(
+				"change %s of VCS root %s and change %s of VCS root %s are in the range, bisecting changes of several VCS roots is not supported",
+				changes[0].Version, root, change.Version, other)
+		}
+	}
+	return nil
+}
+
+// vcsRoot returns the id of the VCS root instance of a change
+func vcsRoot(change teamcity.TCChange) string {
+	if change.VcsRootInstance == nil {
+		return ""
+	}
+	return change.VcsRootInstance.ID
+}
@@ -0,0 +1,180 @@
+package bisect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+)
+
+const (
+	goodBuildID = 1
+	badBuildID  = 2
+)
+
+// fakeClient has a good and a bad build with changes 1 to changes
+// between them. Builds of changes from firstBad on fail
+type fakeClient struct {
+	changes   int
+	firstBad  int
+	statuses  map[int]string // Status of the good and bad builds keyed by id
+	override  map[int]string // Status of the builds of changes keyed by change id
+	roots     map[int]string // VCS root instance of the changes keyed by change id, "1" by default
+	started   []teamcity.StartBuildOptions
+	changeIDs map[int]int // Change id keyed by build id
+}
+
+func (c *fakeClient) GetBuildDetails(ctx context.Context, id int) (teamcity.TCBuildDetails, error) {
+	status, ok := c.statuses[id]
+	if !ok {
+		return teamcity.TCBuildDetails{}, fmt.Errorf("build %d not found", id)
+	}
+	return teamcity.TCBuildDetails{ID: id, BuildTypeID: "P1", BranchName: "main", State: "finished", Status: status}, nil
+}
+
+func (c *fakeClient) ChangesBetween(ctx context.Context, fromBuildID, toBuildID int) ([]teamcity.TCChange, error) {
+	changes := []teamcity.TCChange{}
+	for id := c.changes; id > 0; id-- {
+		root, ok := c.roots[id]
+		if !ok {
+			root = "1"
+		}
+		changes = append(changes, teamcity.TCChange{
+			ID:              id,
+			Version:         fmt.Sprintf("c%d", id),
+			VcsRootInstance: &teamcity.TCVcsRootInstance{ID: root},
+		})
+	}
+	return changes, nil
+}
+
+func (c *fakeClient) StartBuildWithOptions(ctx context.Context, options teamcity.StartBuildOptions) (int, error) {
+	c.started = append(c.started, options)
+	id := 100 + len(c.started)
+	if c.changeIDs == nil {
+		c.changeIDs = map[int]int{}
+	}
+	c.changeIDs[id] = options.ChangeID
+	return id, nil
+}
+
+func (c *fakeClient) WaitForBuild(ctx context.Context, id int, pollInterval time.Duration) (teamcity.TCBuildDetails, error) {
+	change := c.changeIDs[id]
+	status := "SUCCESS"
+	if change >= c.firstBad {
+		status = "FAILURE"
+	}
+	if s, ok := c.override[change]; ok {
+		status = s
+	}
+	return teamcity.TCBuildDetails{ID: id, State: "finished", Status: status}, nil
+}
+
+func newFakeClient(changes, firstBad int) *fakeClient {
+	return &fakeClient{
+		changes:  changes,
+		firstBad: firstBad,
+		statuses: map[int]string{goodBuildID: "SUCCESS", badBuildID: "FAILURE"},
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		changes, firstBad int
+		wantLastGood      int
+		maxBuilds         int
+	}{
+		{changes: 1, firstBad: 1, wantLastGood: goodBuildID, maxBuilds: 0},
+		{changes: 8, firstBad: 1, wantLastGood: goodBuildID, maxBuilds: 3},
+		{changes: 8, firstBad: 5, maxBuilds: 3},
+		{changes: 8, firstBad: 8, maxBuilds: 3},
+		{changes: 100, firstBad: 37, maxBuilds: 7},
+	}
+
+	for _, test := range tests {
+		client := newFakeClient(test.changes, test.firstBad)
+
+		var steps []Step
+		result, err := Run(context.Background(), client, goodBuildID, badBuildID, Options{
+			OnStep: func(step Step) { steps = append(steps, step) },
+		})
+		if err != nil {
+			t.Errorf("%d changes, first bad %d: %v", test.changes, test.firstBad, err)
+			continue
+		}
+
+		if result.FirstBadChange.ID != test.firstBad {
+			t.Errorf("%d changes: got first bad change %d, want %d", test.changes, result.FirstBadChange.ID, test.firstBad)
+		}
+		if result.Candidates != test.changes || len(result.Steps) != len(client.started) || len(steps) != len(client.started) {
+			t.Errorf("%d changes: got %d candidates, %d steps and %d builds", test.changes, result.Candidates, len(result.Steps), len(client.started))
+		}
+		if len(client.started) > test.maxBuilds {
+			t.Errorf("%d changes: got %d builds, want at most %d", test.changes, len(client.started), test.maxBuilds)
+		}
+		if test.wantLastGood != 0 && result.LastGoodBuild.ID != test.wantLastGood {
+			t.Errorf("%d changes: got last good build %d, want %d", test.changes, result.LastGoodBuild.ID, test.wantLastGood)
+		}
+		if result.FirstBadBuild.Status != "FAILURE" || result.LastGoodBuild.Status != "SUCCESS" {
+			t.Errorf("%d changes: got first bad build %+v and last good build %+v", test.changes, result.FirstBadBuild, result.LastGoodBuild)
+		}
+
+		for _, options := range client.started {
+			if options.BuildTypeID != "P1" || options.Branch != "main" || options.ChangeID == 0 {
+				t.Errorf("%d changes: unexpected bisection build %+v", test.changes, options)
+			}
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*fakeClient)
+		err    string
+	}{
+		{
+			name:   "good build failed",
+			modify: func(c *fakeClient) { c.statuses[goodBuildID] = "FAILURE" },
+			err:    "good build 1 finished with status FAILURE",
+		},
+		{
+			name:   "bad build succeeded",
+			modify: func(c *fakeClient) { c.statuses[badBuildID] = "SUCCESS" },
+			err:    "bad build 2 finished with status SUCCESS",
+		},
+		{
+			name:   "bad build missing",
+			modify: func(c *fakeClient) { delete(c.statuses, badBuildID) },
+			err:    "build 2 not found",
+		},
+		{
+			name:   "no changes",
+			modify: func(c *fakeClient) { c.changes = 0 },
+			err:    "there are no changes",
+		},
+		{
+			name:   "several vcs roots",
+			modify: func(c *fakeClient) { c.roots = map[int]string{3: "2"} },
+			err:    "change c3 of VCS root 2 are in the range",
+		},
+		{
+			name:   "cancelled bisection build",
+			modify: func(c *fakeClient) { c.override = map[int]string{4: "UNKNOWN"} },
+			err:    "change c4 finished with status UNKNOWN",
+		},
+	}
+
+	for _, test := range tests {
+		client := newFakeClient(8, 5)
+		test.modify(client)
+
+		_, err := Run(context.Background(), client, goodBuildID, badBuildID, Options{})
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
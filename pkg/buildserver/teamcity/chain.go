@@ -0,0 +1,107 @@
+package teamcity
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+const chainBuildFields = "id,buildTypeId,number,status,state,branchName,statusText,webUrl,snapshot-dependencies(build(id))"
+
+/*
+GetBuildChain returns the snapshot dependency graph of a build
+
+The graph contains the build, all builds it depends upon directly or
+transitively (upstream) and all builds that depend upon it directly or
+transitively (downstream)
+*/
+func (t *TCClient) GetBuildChain(ctx context.Context, id int) (TCBuildChain, error) {
+	chain := TCBuildChain{
+		Root:   id,
+		Builds: []TCBuildDetails{},
+		Edges:  []TCBuildChainEdge{},
+	}
+
+	builds := map[int]TCBuildDetails{}
+	for _, direction := range []string{"to", "from"} {
+		locator := fmt.Sprintf(
+			"snapshotDependency:(%s:(id:%d),includeInitial:true),defaultFilter:false,count:%d",
+			direction, id, defaultPageSize)
+		path := fmt.Sprintf("/app/rest/builds?locator=%s&fields=count,nextHref,build(%s)", url.QueryEscape(locator), chainBuildFields)
+
+		for path != "" {
+			var page TCBuildSnapshotDependencies
+			if err := t.doRequest(ctx, "GET", path, nil, &page); err != nil {
+				return chain, err
+			}
+			for _, build := range page.Builds {
+				builds[build.ID] = build
+			}
+			path = page.NextHref
+		}
+	}
+
+	edges := map[TCBuildChainEdge]bool{}
+	for _, build := range builds {
+		if build.SnapshotDependencies == nil {
+			continue
+		}
+		for _, dependency := range build.SnapshotDependencies.Builds {
+			if _, ok := builds[dependency.ID]; ok {
+				edges[TCBuildChainEdge{From: dependency.ID, To: build.ID}] = true
+			}
+		}
+	}
+
+	for _, build := range builds {
+		chain.Builds = append(chain.Builds, build)
+	}
+	sort.Slice(chain.Builds, func(i, j int) bool { return chain.Builds[i].ID < chain.Builds[j].ID })
+
+	for edge := range edges {
+		chain.Edges = append(chain.Edges, edge)
+	}
+	sort.Slice(chain.Edges, func(i, j int) bool {
+		if chain.Edges[i].To != chain.Edges[j].To {
+			return chain.Edges[i].To < chain.Edges[j].To
+		}
+		return chain.Edges[i].From < chain.Edges[j].From
+	})
+
+	return chain, nil
+}
+
+// Build returns the build of the chain with the provided id
+func (c TCBuildChain) Build(id int) (TCBuildDetails, bool) {
+	for _, build := range c.Builds {
+		if build.ID == id {
+			return build, true
+		}
+	}
+	return TCBuildDetails{}, false
+}
+
+// Dependencies returns the IDs of the builds that the
+// build with the provided id directly depends upon
+func (c TCBuildChain) Dependencies(id int) []int {
+	ids := []int{}
+	for _, edge := range c.Edges {
+		if edge.To == id {
+			ids = append(ids, edge.From)
+		}
+	}
+	return ids
+}
+
+// Dependents returns the IDs of the builds that directly
+// depend upon the build with the provided id
+func (c TCBuildChain) Dependents(id int) []int {
+	ids := []int{}
+	for _, edge := range c.Edges {
+		if edge.From == id {
+			ids = append(ids, edge.To)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,77 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetBuildChain(t *testing.T) {
+	// 1 <- 2 <- 4 and 1 <- 3 <- 4, 4 being the build the chain is requested for.
+	// 5 depends on 4 and is only returned by the downstream query
+	dependsOn := func(ids ...int) *TCBuildSnapshotDependencies {
+		dependencies := &TCBuildSnapshotDependencies{}
+		for _, id := range ids {
+			dependencies.Builds = append(dependencies.Builds, TCBuildDetails{ID: id})
+		}
+		return dependencies
+	}
+	upstream := []TCBuildDetails{
+		{ID: 4, SnapshotDependencies: dependsOn(2, 3)},
+		{ID: 3, SnapshotDependencies: dependsOn(1)},
+		{ID: 2, SnapshotDependencies: dependsOn(1)},
+		{ID: 1},
+	}
+	downstream := []TCBuildDetails{
+		{ID: 5, SnapshotDependencies: dependsOn(4, 99)},
+		upstream[0],
+	}
+
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		locator := r.URL.Query().Get("locator")
+
+		builds := upstream
+		if strings.HasPrefix(locator, "snapshotDependency:(from:(id:4)") {
+			builds = downstream
+		}
+
+		// Serve two builds per page
+		page := TCBuildSnapshotDependencies{Builds: builds}
+		if r.URL.Query().Get("page") != "2" {
+			page.Builds = builds[:2]
+			if len(builds) > 2 {
+				page.NextHref = r.URL.Path + "?" + r.URL.RawQuery + "&page=2"
+			}
+		} else {
+			page.Builds = builds[2:]
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+	defer server.Close()
+
+	chain, err := client.GetBuildChain(context.Background(), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []int{}
+	for _, build := range chain.Builds {
+		ids = append(ids, build.ID)
+	}
+	if len(ids) != 5 || ids[0] != 1 || ids[4] != 5 {
+		t.Errorf("got builds %v, want 1 to 5", ids)
+	}
+
+	want := []TCBuildChainEdge{{From: 1, To: 2}, {From: 1, To: 3}, {From: 2, To: 4}, {From: 3, To: 4}, {From: 4, To: 5}}
+	if len(chain.Edges) != len(want) {
+		t.Fatalf("got edges %v, want %v", chain.Edges, want)
+	}
+	for i := range want {
+		if chain.Edges[i] != want[i] {
+			t.Errorf("got edges %v, want %v", chain.Edges, want)
+			break
+		}
+	}
+}
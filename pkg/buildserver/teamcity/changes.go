@@ -0,0 +1,117 @@
+package teamcity
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+const changeFields = "id,version,username,user(id,username,name,email),date,comment,webUrl," +
+	"vcsRootInstance(id,vcs-root-id,name),files(count,file(file,relative-file,changeType,before-revision,after-revision))"
+
+// ListChanges returns the VCS changes included in a build, newest first
+func (t *TCClient) ListChanges(ctx context.Context, buildID int) ([]TCChange, error) {
+	return t.listChanges(ctx, fmt.Sprintf("build:(id:%d)", buildID))
+}
+
+// GetChange returns the details of a change including the modified files
+func (t *TCClient) GetChange(ctx context.Context, changeID int) (TCChange, error) {
+	var change TCChange
+	err := t.doRequest(ctx, "GET", fmt.Sprintf("/app/rest/changes/id:%d?fields=%s", changeID, changeFields), nil, &change)
+	return change, err
+}
+
+// listChanges follows the pages of changes matching the locator
+func (t *TCClient) listChanges(ctx context.Context, locator string) ([]TCChange, error) {
+	path := fmt.Sprintf(
+		"/app/rest/changes?locator=%s&fields=count,nextHref,change(%s)",
+		url.QueryEscape(locator),
+		changeFields)
+
+	changes := []TCChange{}
+	for path != "" {
+		var page TCChanges
+		if err := t.doRequest(ctx, "GET", path, nil, &page); err != nil {
+			return nil, err
+		}
+		changes = append(changes, page.Change...)
+		path = page.NextHref
+	}
+
+	return changes, nil
+}
+
+/*
+ChangesBetween returns the changes that went into the builds of a pipeline
+after the build fromBuildID up to and including the build toBuildID,
+newest first
+
+Both builds must belong to the same pipeline and fromBuildID must be
+older than toBuildID. Builds on the branch of
+toBuildID are walked and changes of the same commit detected through
+several VCS roots are returned once
+*/
+func (t *TCClient) ChangesBetween(ctx context.Context, fromBuildID, toBuildID int) ([]TCChange, error) {
+	if fromBuildID >= toBuildID {
+		return nil, fmt.Errorf("build %d is not older than build %d, the range of builds is empty", fromBuildID, toBuildID)
+	}
+
+	from, err := t.GetBuildDetails(ctx, fromBuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := t.GetBuildDetails(ctx, toBuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.BuildTypeID != to.BuildTypeID {
+		return nil, fmt.Errorf(
+			"build %d of pipeline %s and build %d of pipeline %s belong to different pipelines",
+			from.ID, from.BuildTypeID, to.ID, to.BuildTypeID)
+	}
+
+	builds, err := ListBuilds(ctx, t, TCQueryParams{
+		BuildTypeID: to.BuildTypeID,
+		Branch:      to.BranchName,
+		SinceBuild:  from.ID,
+		Fields:      "id",
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int{to.ID}
+	for _, build := range builds {
+		if build.ID < to.ID && build.ID > from.ID {
+			ids = append(ids, build.ID)
+		}
+	}
+
+	seen := map[string]bool{}
+	changes := []TCChange{}
+	for _, id := range ids {
+		buildChanges, err := t.ListChanges(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range buildChanges {
+			key := change.Version
+			if key == "" {
+				key = fmt.Sprintf("id:%d", change.ID)
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			changes = append(changes, change)
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].ID > changes[j].ID
+	})
+	return changes, nil
+}
@@ -0,0 +1,125 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestListChanges(t *testing.T) {
+	var locators, fields []string
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/app/rest/changes" {
+			http.Error(w, "unexpected request", http.StatusNotFound)
+			return
+		}
+		locators = append(locators, r.URL.Query().Get("locator"))
+		fields = append(fields, r.URL.Query().Get("fields"))
+
+		// The second page is linked through nextHref
+		if r.URL.Query().Get("page") == "2" {
+			json.NewEncoder(w).Encode(TCChanges{Change: []TCChange{{ID: 1, Version: "a"}}})
+			return
+		}
+		json.NewEncoder(w).Encode(TCChanges{
+			NextHref: "/app/rest/changes?locator=build:(id:7)&fields=count,nextHref,change(id)&page=2",
+			Change:   []TCChange{{ID: 3, Version: "c"}, {ID: 2, Version: "b"}},
+		})
+	})
+	defer server.Close()
+
+	changes, err := client.ListChanges(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 || changes[0].ID != 3 || changes[2].ID != 1 {
+		t.Errorf("got changes %+v, want both pages newest first", changes)
+	}
+	if len(locators) != 2 || locators[0] != "build:(id:7)" {
+		t.Errorf("got locators %v", locators)
+	}
+	if !strings.HasPrefix(fields[0], "count,nextHref,change(") || !strings.Contains(fields[0], "files(") {
+		t.Errorf("got fields %q, want the changes with their files", fields[0])
+	}
+}
+
+func TestGetChange(t *testing.T) {
+	var fields string
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/app/rest/changes/id:5" {
+			http.Error(w, "no such change", http.StatusNotFound)
+			return
+		}
+		fields = r.URL.Query().Get("fields")
+		fmt.Fprint(w, `{"id":5,"version":"abc","username":"jdoe","files":{"count":1,"file":[{"file":"main.go","changeType":"edited"}]}}`)
+	})
+	defer server.Close()
+
+	change, err := client.GetChange(context.Background(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.ID != 5 || change.Version != "abc" || change.Username != "jdoe" ||
+		change.Files == nil || len(change.Files.File) != 1 || change.Files.File[0].ChangeType != "edited" {
+		t.Errorf("got change %+v", change)
+	}
+	if fields != changeFields {
+		t.Errorf("got fields %q, want %q", fields, changeFields)
+	}
+
+	if _, err := client.GetChange(context.Background(), 6); err == nil || !strings.Contains(err.Error(), "no such change") {
+		t.Errorf("got error %v, want the error of teamcity", err)
+	}
+}
+
+func TestChangesBetween(t *testing.T) {
+	requests := 0
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		var out interface{}
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/app/rest/builds/id:"):
+			var id int
+			if _, err := fmt.Sscanf(r.URL.Path, "/app/rest/builds/id:%d", &id); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			out = TCBuildDetails{ID: id, BuildTypeID: "P1", BranchName: "main"}
+		case r.URL.Path == "/app/rest/builds":
+			out = TCBuildSnapshotDependencies{Builds: []TCBuildDetails{{ID: 30}, {ID: 20}}}
+		case r.URL.Path == "/app/rest/changes":
+			changes := map[string][]TCChange{
+				"build:(id:30)": {{ID: 3, Version: "c3"}},
+				"build:(id:20)": {{ID: 2, Version: "c2"}, {ID: 1, Version: "c3"}},
+			}
+			out = TCChanges{Change: changes[r.URL.Query().Get("locator")]}
+		default:
+			http.Error(w, "unexpected request", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(out)
+	})
+	defer server.Close()
+
+	changes, err := client.ChangesBetween(context.Background(), 10, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Version != "c3" || changes[1].Version != "c2" {
+		t.Errorf("got changes %+v, want c3 and c2 once each, newest first", changes)
+	}
+
+	requests = 0
+	for _, builds := range [][2]int{{30, 30}, {30, 10}} {
+		if _, err := client.ChangesBetween(context.Background(), builds[0], builds[1]); err == nil {
+			t.Errorf("ChangesBetween(%d, %d) returned no error for an empty range", builds[0], builds[1])
+		}
+	}
+	if requests != 0 {
+		t.Errorf("got %d requests for empty ranges, want none", requests)
+	}
+}
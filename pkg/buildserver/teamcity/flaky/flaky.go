@@ -0,0 +1,324 @@
+/*
+Package flaky detects tests that fail intermittently by scanning
+the test results of a pipeline across its build history
+
+A change of status of a test is unexplained when it happens on a revision
+the test already ran on with the other status, or when none of the changes
+that went into the builds since the previous run of the test touch a file
+related to the test. A file is related when its name, without extension
+and test suffix, or the name of its directory is one of the words of the
+test name, e.g. src/cache/LruCache.java and pkg/cache/lru.go are related
+to the tests com.acme.cache.LruCacheTest.testEvict and
+github.com/acme/app/pkg/cache: TestEvict
+*/
+package flaky
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+)
+
+// defaultConcurrency is the number of builds whose test
+// results are fetched in parallel when not configured
+const defaultConcurrency = 8
+
+// Client is the subset of the teamcity client used to detect flaky tests
+type Client interface {
+	teamcity.BuildLister
+	ListTestOccurrences(ctx context.Context, buildID int, params teamcity.TCTestQueryParams) ([]teamcity.TCTestOccurrence, error)
+	ListChanges(ctx context.Context, buildID int) ([]teamcity.TCChange, error)
+}
+
+// Options ...
+type Options struct {
+	BuildTypeID string // Pipeline name (BuildConfig ID)
+	Branch      string // Branch name, default branch when empty
+	Builds      uint   // Number of most recent builds to scan
+	Concurrency int    // Number of builds whose test results and changes are fetched in parallel
+}
+
+// TestReport describes how a single test behaved across the scanned builds
+type TestReport struct {
+	Name              string  `json:"name"`
+	Runs              int     `json:"runs"`
+	Failures          int     `json:"failures"`
+	Flips             int     `json:"flips"`             // Number of times the status changed between consecutive runs
+	UnexplainedFlips  int     `json:"unexplainedFlips"`  // Runs whose status changed on the same revision or without related changes
+	MixedRevisions    int     `json:"mixedRevisions"`    // Revisions on which the test both passed and failed
+	Score             float64 `json:"score"`             // Flakiness score between 0 and 1
+	LastFailedBuildID int     `json:"lastFailedBuildId"` // ID of the most recent build the test failed in
+}
+
+// Flaky reports whether the test flipped between pass and fail
+// on the same revision or without related changes
+func (r TestReport) Flaky() bool {
+	return r.UnexplainedFlips > 0
+}
+
+// Detect scans the test results and changes of the last builds of a
+// pipeline and returns the tests that flip between pass and fail on the
+// same revision or without related changes, ranked by flakiness score
+func Detect(ctx context.Context, client Client, options Options) ([]TestReport, error) {
+	builds, err := teamcity.ListBuilds(ctx, client, teamcity.TCQueryParams{
+		BuildTypeID: options.BuildTypeID,
+		Branch:      options.Branch,
+		Fields:      "id,buildTypeId,number,status,state,branchName,webUrl,revisions(revision(version,vcs-root-instance(id)))",
+	}, options.Builds)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu          sync.Mutex
+		occurrences = make(map[int][]teamcity.TCTestOccurrence, len(builds))
+		changes     = make(map[int][]teamcity.TCChange, len(builds))
+	)
+	err = forEachBuild(ctx, builds, options.Concurrency, func(ctx context.Context, id int) error {
+		buildOccurrences, err := client.ListTestOccurrences(ctx, id, teamcity.TCTestQueryParams{})
+		if err != nil {
+			return err
+		}
+		buildChanges, err := client.ListChanges(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		occurrences[id] = buildOccurrences
+		changes[id] = buildChanges
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []TestReport{}
+	for _, report := range Analyze(builds, occurrences, changes) {
+		if report.Flaky() {
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+// forEachBuild calls fn for every build with at most concurrency calls
+// in parallel. It returns the first error, after which ctx is cancelled
+func forEachBuild(
+	ctx context.Context,
+	builds []teamcity.TCBuildDetails,
+	concurrency int,
+	fn func(ctx context.Context, id int) error) error {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		sem      = make(chan struct{}, concurrency)
+	)
+	for _, build := range builds {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := fn(ctx, id); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+			}
+		}(build.ID)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// Analyze computes a report for every test that ran in the builds.
+// builds are expected newest first as returned by teamcity, occurrences
+// are the test results and changes the changes that went into every build
+// keyed by build id. Changes of builds missing from changes are unknown
+// and never explain a flip. Reports are ranked by flakiness score,
+// highest first
+func Analyze(
+	builds []teamcity.TCBuildDetails,
+	occurrences map[int][]teamcity.TCTestOccurrence,
+	changes map[int][]teamcity.TCChange) []TestReport {
+	type run struct {
+		build    int // Index of the build, oldest first
+		buildID  int
+		revision string
+		failed   bool
+	}
+
+	// Builds oldest first
+	ordered := make([]teamcity.TCBuildDetails, 0, len(builds))
+	for i := len(builds) - 1; i >= 0; i-- {
+		ordered = append(ordered, builds[i])
+	}
+
+	// Collect the runs of every test oldest build first
+	runs := map[string][]run{}
+	for i, build := range ordered {
+		revision := revisionKey(build)
+		for _, occurrence := range occurrences[build.ID] {
+			if occurrence.Ignored || (occurrence.Status != "SUCCESS" && occurrence.Status != "FAILURE") {
+				continue
+			}
+			runs[occurrence.Name] = append(runs[occurrence.Name], run{
+				build:    i,
+				buildID:  build.ID,
+				revision: revision,
+				failed:   occurrence.Status == "FAILURE",
+			})
+		}
+	}
+
+	reports := make([]TestReport, 0, len(runs))
+	for name, testRuns := range runs {
+		report := TestReport{Name: name, Runs: len(testRuns)}
+
+		// Status seen per revision, bit 1 for pass and bit 2 for fail,
+		// and the status of the last run on every revision
+		outcomes := map[string]int{}
+		lastFailed := map[string]bool{}
+		for i, r := range testRuns {
+			if r.failed {
+				report.Failures++
+				report.LastFailedBuildID = r.buildID
+			}
+			flipped := i > 0 && testRuns[i-1].failed != r.failed
+			if flipped {
+				report.Flips++
+			}
+
+			failed, ran := lastFailed[r.revision]
+			switch {
+			case r.revision != "" && ran && failed != r.failed:
+				report.UnexplainedFlips++
+			case flipped && !relatedChanges(name, ordered[testRuns[i-1].build+1:r.build+1], changes):
+				report.UnexplainedFlips++
+			}
+
+			if r.revision == "" {
+				continue
+			}
+			lastFailed[r.revision] = r.failed
+			if r.failed {
+				outcomes[r.revision] |= 2
+			} else {
+				outcomes[r.revision] |= 1
+			}
+		}
+
+		for _, outcome := range outcomes {
+			if outcome == 3 {
+				report.MixedRevisions++
+			}
+		}
+
+		// Every run adds to the unexplained flips at most once, hence
+		// the score stays below 1
+		report.Score = float64(report.UnexplainedFlips) / float64(report.Runs)
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Score != reports[j].Score {
+			return reports[i].Score > reports[j].Score
+		}
+		if reports[i].Flips != reports[j].Flips {
+			return reports[i].Flips > reports[j].Flips
+		}
+		return reports[i].Name < reports[j].Name
+	})
+	return reports
+}
+
+// revisionKey identifies the sources a build was built from. It is
+// empty when the revisions of the build are unknown
+func revisionKey(build teamcity.TCBuildDetails) string {
+	if build.Revisions == nil {
+		return ""
+	}
+
+	versions := []string{}
+	for _, revision := range build.Revisions.Revision {
+		root := ""
+		if revision.VcsRootInstance != nil {
+			root = revision.VcsRootInstance.ID
+		}
+		versions = append(versions, root+"@"+revision.Version)
+	}
+	sort.Strings(versions)
+	return strings.Join(versions, ",")
+}
+
+// relatedChanges reports whether any change that went into the builds
+// touches a file related to the test. Unknown changes count as related
+func relatedChanges(test string, builds []teamcity.TCBuildDetails, changes map[int][]teamcity.TCChange) bool {
+	words := testWords(test)
+	for _, build := range builds {
+		buildChanges, ok := changes[build.ID]
+		if !ok {
+			return true
+		}
+		for _, change := range buildChanges {
+			if change.Files == nil {
+				return true
+			}
+			for _, file := range change.Files.File {
+				if relatedFile(words, file.File) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// testWords returns the lower case words of a test name, along
+// with the words without a test prefix or suffix
+func testWords(test string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(test), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[word] = true
+		if trimmed := strings.TrimSuffix(strings.TrimPrefix(word, "test"), "test"); trimmed != "" {
+			words[trimmed] = true
+		}
+	}
+	return words
+}
+
+// relatedFile reports whether the name of a file, without extension and
+// test suffix, or the name of its directory is one of the words of a test
+func relatedFile(words map[string]bool, file string) bool {
+	file = strings.ToLower(strings.ReplaceAll(file, "\\", "/"))
+
+	name := path.Base(file)
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "test"), "_")
+
+	dir := path.Base(path.Dir(file))
+	return words[name] || (dir != "." && dir != "/" && words[dir])
+}
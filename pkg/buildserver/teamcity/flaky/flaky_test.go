@@ -0,0 +1,282 @@
+package flaky
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+)
+
+// history returns builds newest first with the ids 1..len(revisions)
+// in order of age, the oldest build getting the first revision
+func history(revisions ...string) []teamcity.TCBuildDetails {
+	builds := make([]teamcity.TCBuildDetails, len(revisions))
+	for i, revision := range revisions {
+		build := teamcity.TCBuildDetails{ID: i + 1}
+		if revision != "" {
+			build.Revisions = &teamcity.TCRevisions{Revision: []teamcity.TCRevision{{Version: revision}}}
+		}
+		builds[len(revisions)-1-i] = build
+	}
+	return builds
+}
+
+// results returns the occurrences of a single test keyed by build
+// id, statuses being given in the order of the builds
+func results(statuses ...string) map[int][]teamcity.TCTestOccurrence {
+	occurrences := map[int][]teamcity.TCTestOccurrence{}
+	for i, status := range statuses {
+		if status != "" {
+			occurrences[i+1] = []teamcity.TCTestOccurrence{{Name: "test", Status: status}}
+		}
+	}
+	return occurrences
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name      string
+		revisions []string
+		statuses  []string
+		want      TestReport
+	}{
+		{
+			name:      "stable",
+			revisions: []string{"a", "b", "c"},
+			statuses:  []string{"SUCCESS", "SUCCESS", "SUCCESS"},
+			want:      TestReport{Runs: 3},
+		},
+		{
+			name:      "fixed by a new revision",
+			revisions: []string{"a", "b", "c"},
+			statuses:  []string{"FAILURE", "SUCCESS", "SUCCESS"},
+			want:      TestReport{Runs: 3, Failures: 1, Flips: 1, LastFailedBuildID: 1},
+		},
+		{
+			name:      "flip on the same revision",
+			revisions: []string{"a", "a"},
+			statuses:  []string{"SUCCESS", "FAILURE"},
+			want:      TestReport{Runs: 2, Failures: 1, Flips: 1, UnexplainedFlips: 1, MixedRevisions: 1, Score: 0.5, LastFailedBuildID: 2},
+		},
+		{
+			name:      "flipping on every run",
+			revisions: []string{"a", "a", "a", "a"},
+			statuses:  []string{"SUCCESS", "FAILURE", "SUCCESS", "FAILURE"},
+			want:      TestReport{Runs: 4, Failures: 2, Flips: 3, UnexplainedFlips: 3, MixedRevisions: 1, Score: 0.75, LastFailedBuildID: 4},
+		},
+		{
+			name:      "rebuild of an earlier revision",
+			revisions: []string{"a", "b", "a"},
+			statuses:  []string{"SUCCESS", "SUCCESS", "FAILURE"},
+			want:      TestReport{Runs: 3, Failures: 1, Flips: 1, UnexplainedFlips: 1, MixedRevisions: 1, Score: 1.0 / 3, LastFailedBuildID: 3},
+		},
+		{
+			name:      "unknown revisions",
+			revisions: []string{"", ""},
+			statuses:  []string{"SUCCESS", "FAILURE"},
+			want:      TestReport{Runs: 2, Failures: 1, Flips: 1, LastFailedBuildID: 2},
+		},
+		{
+			name:      "ignored and not run",
+			revisions: []string{"a", "a", "a"},
+			statuses:  []string{"SUCCESS", "UNKNOWN", ""},
+			want:      TestReport{Runs: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reports := Analyze(history(test.revisions...), results(test.statuses...), nil)
+			if len(reports) != 1 {
+				t.Fatalf("got %d reports, want 1", len(reports))
+			}
+
+			test.want.Name = "test"
+			if reports[0] != test.want {
+				t.Errorf("got %+v, want %+v", reports[0], test.want)
+			}
+			if reports[0].Flaky() != (test.want.UnexplainedFlips > 0) {
+				t.Errorf("Flaky() = %v", reports[0].Flaky())
+			}
+		})
+	}
+}
+
+func TestAnalyzeRanking(t *testing.T) {
+	builds := history("a", "a", "a", "a")
+	occurrences := map[int][]teamcity.TCTestOccurrence{}
+	for id, statuses := range map[int][]string{
+		1: {"SUCCESS", "SUCCESS", "SUCCESS", "FAILURE"},
+		2: {"FAILURE", "SUCCESS", "SUCCESS", "SUCCESS"},
+		3: {"SUCCESS", "SUCCESS", "SUCCESS", "FAILURE"},
+		4: {"FAILURE", "FAILURE", "SUCCESS", "SUCCESS"},
+	} {
+		for i, name := range []string{"often", "rarely", "stable", "zzz"} {
+			occurrences[id] = append(occurrences[id], teamcity.TCTestOccurrence{Name: name, Status: statuses[i]})
+		}
+	}
+
+	want := []string{"often", "zzz", "rarely", "stable"}
+	reports := Analyze(builds, occurrences, nil)
+	for i, report := range reports {
+		if report.Name != want[i] {
+			t.Fatalf("got ranking %v, want %v", names(reports), want)
+		}
+	}
+}
+
+func names(reports []TestReport) []string {
+	result := []string{}
+	for _, report := range reports {
+		result = append(result, report.Name)
+	}
+	return result
+}
+
+// changed returns the changes of the builds keyed by build id, each
+// build changing the given files. Builds without entry have no changes
+func changed(builds int, files map[int][]string) map[int][]teamcity.TCChange {
+	changes := map[int][]teamcity.TCChange{}
+	for id := 1; id <= builds; id++ {
+		changes[id] = []teamcity.TCChange{}
+		if len(files[id]) == 0 {
+			continue
+		}
+		change := teamcity.TCChange{ID: id, Files: &teamcity.TCChangeFiles{}}
+		for _, file := range files[id] {
+			change.Files.File = append(change.Files.File, teamcity.TCChangeFile{File: file})
+		}
+		changes[id] = append(changes[id], change)
+	}
+	return changes
+}
+
+func TestAnalyzeChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		changes  map[int][]teamcity.TCChange
+		want     int // Unexplained flips
+	}{
+		{
+			name:     "unrelated changes",
+			statuses: []string{"SUCCESS", "FAILURE", "SUCCESS"},
+			changes:  changed(3, map[int][]string{2: {"docs/README.md"}, 3: {"src/main/java/com/acme/http/Server.java"}}),
+			want:     2,
+		},
+		{
+			name:     "no changes",
+			statuses: []string{"SUCCESS", "FAILURE"},
+			changes:  changed(2, nil),
+			want:     1,
+		},
+		{
+			name:     "related class",
+			statuses: []string{"SUCCESS", "FAILURE"},
+			changes:  changed(2, map[int][]string{2: {"docs/README.md", "src/main/java/com/acme/cache/LruCache.java"}}),
+		},
+		{
+			name:     "related package",
+			statuses: []string{"SUCCESS", "FAILURE"},
+			changes:  changed(2, map[int][]string{2: {"src/main/java/com/acme/cache/Entry.java"}}),
+		},
+		{
+			name:     "related change in a build the test did not run in",
+			statuses: []string{"SUCCESS", "", "FAILURE"},
+			changes:  changed(3, map[int][]string{2: {"src/test/java/com/acme/cache/LruCacheTest.java"}}),
+		},
+		{
+			name:     "unknown changes",
+			statuses: []string{"SUCCESS", "FAILURE", "SUCCESS"},
+			changes:  map[int][]teamcity.TCChange{1: {}, 2: {}},
+			want:     1,
+		},
+		{
+			name:     "changes without files",
+			statuses: []string{"SUCCESS", "FAILURE"},
+			changes:  map[int][]teamcity.TCChange{1: {}, 2: {{ID: 2}}},
+		},
+	}
+
+	for _, test := range tests {
+		occurrences := map[int][]teamcity.TCTestOccurrence{}
+		for i, status := range test.statuses {
+			if status != "" {
+				occurrences[i+1] = []teamcity.TCTestOccurrence{{Name: "com.acme.cache.LruCacheTest.testEvict", Status: status}}
+			}
+		}
+		revisions := make([]string, len(test.statuses))
+		for i := range revisions {
+			revisions[i] = string(rune('a' + i))
+		}
+
+		reports := Analyze(history(revisions...), occurrences, test.changes)
+		if len(reports) != 1 || reports[0].UnexplainedFlips != test.want || reports[0].Flaky() != (test.want > 0) {
+			t.Errorf("%s: got reports %+v, want %d unexplained flips", test.name, reports, test.want)
+		}
+	}
+}
+
+func TestRelatedFile(t *testing.T) {
+	tests := []struct {
+		test, file string
+		want       bool
+	}{
+		{"com.acme.cache.LruCacheTest.testEvict", "src/main/java/com/acme/cache/LruCache.java", true},
+		{"com.acme.cache.LruCacheTest.testEvict", "src/test/java/com/acme/cache/LruCacheTest.java", true},
+		{"com.acme.cache.LruCacheTest.testEvict", "src/main/java/com/acme/http/Evict.java", true},
+		{"com.acme.cache.LruCacheTest.testEvict", "src/main/java/com/acme/http/Server.java", false},
+		{"github.com/acme/app/pkg/cache: TestEviction", "pkg/cache/lru.go", true},
+		{"github.com/acme/app/pkg/cache: TestEviction", "pkg/cache/lru_test.go", true},
+		{"github.com/acme/app/pkg/cache: TestEviction", "pkg/http/server.go", false},
+		{"github.com/acme/app/pkg/cache: TestEviction", "README.md", false},
+		{"Cache.spec: evicts entries", "src\\cache.spec.ts", true},
+	}
+
+	for _, test := range tests {
+		if got := relatedFile(testWords(test.test), test.file); got != test.want {
+			t.Errorf("relatedFile(%q, %q) = %v, want %v", test.test, test.file, got, test.want)
+		}
+	}
+}
+
+// fakeClient serves two builds of the same revision
+type fakeClient struct {
+	err error
+}
+
+func (c fakeClient) ListBuildsPage(ctx context.Context, params teamcity.TCQueryParams) (teamcity.TCBuildSnapshotDependencies, error) {
+	if params.Start > 0 {
+		return teamcity.TCBuildSnapshotDependencies{}, nil
+	}
+	return teamcity.TCBuildSnapshotDependencies{Builds: history("a", "b")}, nil
+}
+
+func (c fakeClient) ListTestOccurrences(ctx context.Context, buildID int, params teamcity.TCTestQueryParams) ([]teamcity.TCTestOccurrence, error) {
+	status := map[int]string{1: "SUCCESS", 2: "FAILURE"}[buildID]
+	return []teamcity.TCTestOccurrence{{Name: "flaky", Status: status}, {Name: "stable", Status: "SUCCESS"}}, nil
+}
+
+func (c fakeClient) ListChanges(ctx context.Context, buildID int) ([]teamcity.TCChange, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return []teamcity.TCChange{{ID: buildID, Files: &teamcity.TCChangeFiles{File: []teamcity.TCChangeFile{{File: "docs/index.md"}}}}}, nil
+}
+
+func TestDetect(t *testing.T) {
+	reports, err := Detect(context.Background(), fakeClient{}, Options{BuildTypeID: "P1", Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(reports), []string{"flaky"}) {
+		t.Errorf("got reports %+v, want the test that flipped without related changes", reports)
+	}
+
+	failed := errors.New("changes unavailable")
+	if _, err := Detect(context.Background(), fakeClient{err: failed}, Options{BuildTypeID: "P1"}); err != failed {
+		t.Errorf("got error %v, want %v", err, failed)
+	}
+}
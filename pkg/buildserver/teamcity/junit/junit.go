@@ -0,0 +1,166 @@
+// Package junit converts teamcity test occurrences into JUnit XML reports
+package junit
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+)
+
+// TestSuites is the root element of a JUnit report
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr,omitempty"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     float64     `xml:"time,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+// TestSuite groups the test cases of a suite
+type TestSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Skipped   int        `xml:"skipped,attr"`
+	Time      float64    `xml:"time,attr"`
+	TestCases []TestCase `xml:"testcase"`
+}
+
+// TestCase is the result of a single test
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      float64  `xml:"time,attr"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	Skipped   *Skipped `xml:"skipped,omitempty"`
+}
+
+// Failure describes why a test case failed
+type Failure struct {
+	Message string `xml:"message,attr,omitempty"`
+	Details string `xml:",chardata"`
+}
+
+// Skipped marks a test case that was not run
+type Skipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// NewReport converts test occurrences into a JUnit report with
+// test cases grouped by suite. name is the name of the report
+func NewReport(name string, occurrences []teamcity.TCTestOccurrence) TestSuites {
+	report := TestSuites{Name: name}
+
+	suites := map[string]*TestSuite{}
+	for _, occurrence := range occurrences {
+		suiteName, className, testName := parseTestName(occurrence.Name)
+
+		suite, ok := suites[suiteName]
+		if !ok {
+			suite = &TestSuite{Name: suiteName}
+			suites[suiteName] = suite
+		}
+
+		testCase := TestCase{
+			Name:      testName,
+			ClassName: className,
+			Time:      seconds(occurrence.Duration),
+		}
+
+		switch {
+		case occurrence.Ignored:
+			testCase.Skipped = &Skipped{Message: firstLine(occurrence.Details)}
+			suite.Skipped++
+		case occurrence.Status == "FAILURE":
+			testCase.Failure = &Failure{
+				Message: firstLine(occurrence.Details),
+				Details: occurrence.Details,
+			}
+			suite.Failures++
+		}
+
+		suite.Tests++
+		suite.Time += testCase.Time
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	names := make([]string, 0, len(suites))
+	for name := range suites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		suite := suites[name]
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Time += suite.Time
+		report.Suites = append(report.Suites, *suite)
+	}
+
+	return report
+}
+
+// Encode writes the test occurrences to w as a JUnit XML report
+func Encode(w io.Writer, name string, occurrences []teamcity.TCTestOccurrence) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(NewReport(name, occurrences)); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+/*
+parseTestName splits a teamcity test name into suite, class and test name
+
+Teamcity test names have the form "suite: package.Class.test(params)"
+where the suite and the params are optional. When the name has no suite
+the class is used as suite
+*/
+func parseTestName(name string) (suite, className, testName string) {
+	if i := strings.Index(name, ": "); i >= 0 {
+		suite, name = name[:i], name[i+2:]
+	}
+
+	// Params may contain '.' hence the class is looked up before them
+	qualified := name
+	if i := strings.Index(name, "("); i >= 0 {
+		qualified = name[:i]
+	}
+
+	testName = name
+	if i := strings.LastIndex(qualified, "."); i >= 0 {
+		className, testName = name[:i], name[i+1:]
+	}
+
+	if suite == "" {
+		suite = className
+	}
+	if suite == "" {
+		suite = "default"
+	}
+	return suite, className, testName
+}
+
+// seconds converts a duration in milliseconds to seconds
+func seconds(milliseconds int) float64 {
+	return (time.Duration(milliseconds) * time.Millisecond).Seconds()
+}
+
+func firstLine(text string) string {
+	return strings.TrimSpace(strings.SplitN(text, "\n", 2)[0])
+}
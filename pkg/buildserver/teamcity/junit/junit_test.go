@@ -0,0 +1,85 @@
+package junit
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+)
+
+func TestParseTestName(t *testing.T) {
+	tests := []struct {
+		name      string
+		suite     string
+		className string
+		testName  string
+	}{
+		{"unit: com.acme.FooTest.testBar", "unit", "com.acme.FooTest", "testBar"},
+		{"com.acme.FooTest.testBar", "com.acme.FooTest", "com.acme.FooTest", "testBar"},
+		{"com.acme.FooTest.testBar(a.b, 1.5)", "com.acme.FooTest", "com.acme.FooTest", "testBar(a.b, 1.5)"},
+		{"e2e: login works", "e2e", "", "login works"},
+		{"standalone", "default", "", "standalone"},
+	}
+
+	for _, test := range tests {
+		suite, className, testName := parseTestName(test.name)
+		if suite != test.suite || className != test.className || testName != test.testName {
+			t.Errorf("parseTestName(%q) = %q, %q, %q, want %q, %q, %q",
+				test.name, suite, className, testName, test.suite, test.className, test.testName)
+		}
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	occurrences := []teamcity.TCTestOccurrence{
+		{Name: "unit: pkg.A.passes", Status: "SUCCESS", Duration: 1500},
+		{Name: "unit: pkg.A.fails", Status: "FAILURE", Duration: 500, Details: "expected 1\n  at pkg.A.fails"},
+		{Name: "unit: pkg.A.ignored", Status: "UNKNOWN", Ignored: true, Details: "flaky"},
+		{Name: "api: pkg.B.passes", Status: "SUCCESS", Duration: 2000},
+	}
+
+	report := NewReport("build 42", occurrences)
+
+	if report.Name != "build 42" || report.Tests != 4 || report.Failures != 1 || report.Skipped != 1 || report.Time != 4 {
+		t.Fatalf("unexpected report totals %+v", report)
+	}
+	if len(report.Suites) != 2 || report.Suites[0].Name != "api" || report.Suites[1].Name != "unit" {
+		t.Fatalf("suites are not sorted by name: %+v", report.Suites)
+	}
+
+	unit := report.Suites[1]
+	if unit.Tests != 3 || unit.Failures != 1 || unit.Skipped != 1 || unit.Time != 2 {
+		t.Errorf("unexpected unit suite totals %+v", unit)
+	}
+
+	failed := unit.TestCases[1]
+	if failed.Failure == nil || failed.Failure.Message != "expected 1" || failed.Failure.Details != occurrences[1].Details {
+		t.Errorf("unexpected failure %+v", failed.Failure)
+	}
+	if skipped := unit.TestCases[2]; skipped.Skipped == nil || skipped.Skipped.Message != "flaky" || skipped.Failure != nil {
+		t.Errorf("unexpected skipped test case %+v", skipped)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	var buf bytes.Buffer
+	err := Encode(&buf, "build", []teamcity.TCTestOccurrence{
+		{Name: "pkg.A.fails", Status: "FAILURE", Details: "boom <&>"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("report does not start with the XML header: %s", buf.String())
+	}
+
+	var decoded TestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Failures != 1 || decoded.Suites[0].TestCases[0].Failure.Details != "boom <&>" {
+		t.Errorf("unexpected decoded report %+v", decoded)
+	}
+}
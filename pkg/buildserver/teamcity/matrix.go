@@ -0,0 +1,93 @@
+package teamcity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ExpandMatrix returns the cartesian product of the axes values. The
+// combinations are ordered with the values of the last axis changing
+// fastest
+func ExpandMatrix(axes []TCMatrixAxis) []map[string]string {
+	combinations := []map[string]string{{}}
+	for _, axis := range axes {
+		expanded := []map[string]string{}
+		for _, combination := range combinations {
+			for _, value := range axis.Values {
+				next := make(map[string]string, len(combination)+1)
+				for k, v := range combination {
+					next[k] = v
+				}
+				next[axis.Name] = value
+				expanded = append(expanded, next)
+			}
+		}
+		combinations = expanded
+	}
+	return combinations
+}
+
+/*
+StartMatrix adds one build per combination of axes values to the build queue
+
+options describes the build common to all combinations. The axis values of a
+combination are added to the params of its build and the build is tagged
+with them in the form name=value.
+
+It returns a run for every combination. A combination that could not be
+queued has the reason in its Error
+*/
+func (t *TCClient) StartMatrix(ctx context.Context, options StartBuildOptions, axes []TCMatrixAxis) []TCMatrixRun {
+	runs := []TCMatrixRun{}
+	for _, values := range ExpandMatrix(axes) {
+		runOptions := options
+		runOptions.Params = make(map[string]string, len(options.Params)+len(values))
+		for k, v := range options.Params {
+			runOptions.Params[k] = v
+		}
+		runOptions.Tags = append([]string{}, options.Tags...)
+		for _, axis := range axes {
+			runOptions.Params[axis.Name] = values[axis.Name]
+			runOptions.Tags = append(runOptions.Tags, fmt.Sprintf("%s=%s", axis.Name, values[axis.Name]))
+		}
+
+		run := TCMatrixRun{Values: values}
+		id, err := t.StartBuildWithOptions(ctx, runOptions)
+		if err != nil {
+			run.Error = err.Error()
+		} else {
+			run.BuildID = id
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// WaitForMatrix waits for all queued builds of a matrix to finish
+// and returns the runs with their final build details
+func (t *TCClient) WaitForMatrix(ctx context.Context, runs []TCMatrixRun, pollInterval time.Duration) []TCMatrixRun {
+	finished := make([]TCMatrixRun, len(runs))
+	copy(finished, runs)
+
+	var wg sync.WaitGroup
+	for i := range finished {
+		if finished[i].BuildID == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(run *TCMatrixRun) {
+			defer wg.Done()
+			build, err := t.WaitForBuild(ctx, run.BuildID, pollInterval)
+			if err != nil {
+				run.Error = err.Error()
+				return
+			}
+			run.Build = &build
+		}(&finished[i])
+	}
+	wg.Wait()
+
+	return finished
+}
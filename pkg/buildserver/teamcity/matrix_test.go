@@ -0,0 +1,117 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExpandMatrix(t *testing.T) {
+	tests := []struct {
+		name string
+		axes []TCMatrixAxis
+		want []map[string]string
+	}{
+		{
+			name: "no axes",
+			want: []map[string]string{{}},
+		},
+		{
+			name: "single axis",
+			axes: []TCMatrixAxis{{Name: "env.JDK", Values: []string{"8", "11"}}},
+			want: []map[string]string{{"env.JDK": "8"}, {"env.JDK": "11"}},
+		},
+		{
+			name: "last axis changes fastest",
+			axes: []TCMatrixAxis{
+				{Name: "env.OS", Values: []string{"linux", "windows"}},
+				{Name: "env.JDK", Values: []string{"8", "11", "17"}},
+			},
+			want: []map[string]string{
+				{"env.OS": "linux", "env.JDK": "8"},
+				{"env.OS": "linux", "env.JDK": "11"},
+				{"env.OS": "linux", "env.JDK": "17"},
+				{"env.OS": "windows", "env.JDK": "8"},
+				{"env.OS": "windows", "env.JDK": "11"},
+				{"env.OS": "windows", "env.JDK": "17"},
+			},
+		},
+		{
+			name: "axis without values",
+			axes: []TCMatrixAxis{
+				{Name: "env.OS", Values: []string{"linux"}},
+				{Name: "env.JDK"},
+			},
+			want: []map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		if got := ExpandMatrix(test.axes); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestStartMatrix(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		payloads []TCBuildPayload
+	)
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		var payload TCBuildPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		payloads = append(payloads, payload)
+		if len(payloads) == 2 {
+			http.Error(w, "queue is full", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(TCBuildDetails{ID: 100 + len(payloads)})
+	})
+	defer server.Close()
+
+	runs := client.StartMatrix(context.Background(), StartBuildOptions{
+		BuildTypeID: "P1",
+		Params:      map[string]string{"env.COMMON": "yes"},
+		Tags:        []string{"nightly"},
+	}, []TCMatrixAxis{{Name: "env.JDK", Values: []string{"8", "11", "17"}}})
+
+	if len(runs) != 3 {
+		t.Fatalf("got %d runs, want 3", len(runs))
+	}
+	if runs[0].BuildID != 101 || runs[2].BuildID != 103 || runs[0].Error != "" {
+		t.Errorf("unexpected runs %+v", runs)
+	}
+	if runs[1].BuildID != 0 || !strings.Contains(runs[1].Error, "queue is full") {
+		t.Errorf("got run %+v, want the error of the failed request", runs[1])
+	}
+
+	payload := payloads[2]
+	params := map[string]string{}
+	for _, property := range payload.Properties.Property {
+		params[property.Name] = property.Value
+	}
+	if !reflect.DeepEqual(params, map[string]string{"env.COMMON": "yes", "env.JDK": "17"}) {
+		t.Errorf("got params %v", params)
+	}
+
+	tags := []string{}
+	for _, tag := range payload.Tags.Tag {
+		tags = append(tags, tag.Name)
+	}
+	sort.Strings(tags)
+	if !reflect.DeepEqual(tags, []string{"env.JDK=17", "nightly"}) {
+		t.Errorf("got tags %v", tags)
+	}
+}
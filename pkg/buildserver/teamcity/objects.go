@@ -1,5 +1,7 @@
 package teamcity
 
+import "time"
+
 // TCBuildType ...
 type TCBuildType struct {
 	ID          string `json:"id"`
@@ -29,8 +31,9 @@ type TCBuildProperties struct {
 
 // TCBuildSnapshotDependencies ...
 type TCBuildSnapshotDependencies struct {
-	Count  int              `json:"count,omitempty"`
-	Builds []TCBuildDetails `json:"build,omitempty"`
+	Count    int              `json:"count,omitempty"`
+	NextHref string           `json:"nextHref,omitempty"`
+	Builds   []TCBuildDetails `json:"build,omitempty"`
 }
 
 // TCBuildPayload ...
@@ -40,10 +43,33 @@ type TCBuildPayload struct {
 	Properties           TCBuildProperties            `json:"properties,omitempty"`
 	Personal             string                       `json:"personal"` // "true" or "false"
 	BranchName           string                       `json:"branchName,omitempty"`
+	Revisions            *TCRevisions                 `json:"revisions,omitempty"`
+	LastChanges          *TCChanges                   `json:"lastChanges,omitempty"`
+	TriggeringOptions    *TCTriggeringOptions         `json:"triggeringOptions,omitempty"`
+	Agent                *TCAgent                     `json:"agent,omitempty"`
+	Tags                 *TCTags                      `json:"tags,omitempty"`
 	SnapshotDependencies *TCBuildSnapshotDependencies `json:"snapshot-dependencies,omitempty"`
 	ArtifactDependencies *TCBuildSnapshotDependencies `json:"artifact-dependencies,omitempty"`
 }
 
+// TCTriggeringOptions ...
+type TCTriggeringOptions struct {
+	CleanSources           bool `json:"cleanSources,omitempty"`
+	RebuildAllDependencies bool `json:"rebuildAllDependencies,omitempty"`
+	QueueAtTop             bool `json:"queueAtTop,omitempty"`
+}
+
+// TCTag ...
+type TCTag struct {
+	Name string `json:"name"`
+}
+
+// TCTags ...
+type TCTags struct {
+	Count int     `json:"count,omitempty"`
+	Tag   []TCTag `json:"tag"`
+}
+
 // TCBuildDetails ...
 type TCBuildDetails struct {
 	ID                   int                          `json:"id"`
@@ -54,13 +80,85 @@ type TCBuildDetails struct {
 	BranchName           string                       `json:"branchName,omitempty"`
 	WebURL               string                       `json:"webUrl,omitempty"`
 	StatusText           string                       `json:"statusText,omitempty"`
+	WaitReason           string                       `json:"waitReason,omitempty"`
+	QueuedDate           string                       `json:"queuedDate,omitempty"`
+	StartEstimate        string                       `json:"startEstimate,omitempty"`
+	StartDate            string                       `json:"startDate,omitempty"`
+	FinishDate           string                       `json:"finishDate,omitempty"`
+	Agent                *TCAgent                     `json:"agent,omitempty"`
+	CanceledInfo         *TCCanceledInfo              `json:"canceledInfo,omitempty"`
 	Comment              TCBuildComment               `json:"comment,omitempty"`
 	BuildType            TCBuildType                  `json:"buildType,omitempty"`
 	Properties           TCBuildProperties            `json:"properties,omitempty"`
+	Revisions            *TCRevisions                 `json:"revisions,omitempty"`
 	SnapshotDependencies *TCBuildSnapshotDependencies `json:"snapshot-dependencies,omitempty"`
 	ArtifactDependencies *TCBuildSnapshotDependencies `json:"artifact-dependencies,omitempty"`
 }
 
+// TCVcsRootInstance ...
+type TCVcsRootInstance struct {
+	ID        string `json:"id,omitempty"`
+	VcsRootID string `json:"vcs-root-id,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// TCRevision is the VCS revision a build was or will be built on
+type TCRevision struct {
+	Version         string             `json:"version"`
+	VcsBranchName   string             `json:"vcsBranchName,omitempty"`
+	VcsRootInstance *TCVcsRootInstance `json:"vcs-root-instance,omitempty"`
+}
+
+// TCRevisions ...
+type TCRevisions struct {
+	Count    int          `json:"count,omitempty"`
+	Revision []TCRevision `json:"revision"`
+}
+
+// TCChange is a VCS commit detected by teamcity
+type TCChange struct {
+	ID              int                `json:"id,omitempty"`
+	Locator         string             `json:"locator,omitempty"` // Change locator used to refer to a change when triggering builds
+	Version         string             `json:"version,omitempty"`
+	Username        string             `json:"username,omitempty"` // VCS username of the committer
+	User            *TCUser            `json:"user,omitempty"`     // Teamcity user the committer is mapped to
+	Date            string             `json:"date,omitempty"`
+	Comment         string             `json:"comment,omitempty"`
+	WebURL          string             `json:"webUrl,omitempty"`
+	VcsRootInstance *TCVcsRootInstance `json:"vcsRootInstance,omitempty"`
+	Files           *TCChangeFiles     `json:"files,omitempty"`
+}
+
+// TCChanges ...
+type TCChanges struct {
+	Count    int        `json:"count,omitempty"`
+	NextHref string     `json:"nextHref,omitempty"`
+	Change   []TCChange `json:"change"`
+}
+
+// TCChangeFile is a file modified by a change
+type TCChangeFile struct {
+	File           string `json:"file"`
+	RelativeFile   string `json:"relative-file,omitempty"`
+	ChangeType     string `json:"changeType,omitempty"` // added, edited, removed etc.
+	BeforeRevision string `json:"before-revision,omitempty"`
+	AfterRevision  string `json:"after-revision,omitempty"`
+}
+
+// TCChangeFiles ...
+type TCChangeFiles struct {
+	Count int            `json:"count,omitempty"`
+	File  []TCChangeFile `json:"file"`
+}
+
+// TCUser ...
+type TCUser struct {
+	ID       int    `json:"id,omitempty"`
+	Username string `json:"username,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
 // TCBuildStopPayload ...
 type TCBuildStopPayload struct {
 	Comment        string `json:"comment"`
@@ -69,13 +167,249 @@ type TCBuildStopPayload struct {
 
 // TCQueryParams ...
 type TCQueryParams struct {
+	BuildTypeID  string    // Pipeline name (BuildConfig ID)
+	Branch       string    // Branch name
+	Status       string    // Status such as SUCCESS FAILURE UNKNOWN
+	User         string    // Teamcity username
+	AnyBranch    bool      // Builds of all branches when Branch is empty instead of the default branch only
+	Running      bool      // Build running
+	AnyRunning   bool      // Running and finished builds, Running is ignored
+	Cancelled    bool      // Build cancelled
+	AnyCancelled bool      // Cancelled and not cancelled builds, Cancelled is ignored
+	Pinned       bool      // Build pinned
+	Tags         []string  // Builds having all of these tags
+	SinceBuild   int       // Builds started after the build with this ID
+	SinceDate    time.Time // Builds started after this time
+	Start        uint      // Start index when listing builds
+	Count        uint      // Number of build records to return from start index
+	LookupLimit  uint      // Lookup limit that limits teamcity to process the latest N builds only
+	Fields       string    // Comma separated build fields to return, e.g. id,status,revisions. Default fields when empty
+}
+
+// TCAgent ...
+type TCAgent struct {
+	ID         int          `json:"id,omitempty"`
+	Name       string       `json:"name,omitempty"`
+	Locator    string       `json:"locator,omitempty"` // Agent locator used to refer to an agent when triggering builds
+	TypeID     int          `json:"typeId,omitempty"`
+	Connected  bool         `json:"connected,omitempty"`
+	Enabled    bool         `json:"enabled,omitempty"`
+	Authorized bool         `json:"authorized,omitempty"`
+	WebURL     string       `json:"webUrl,omitempty"`
+	Pool       *TCAgentPool `json:"pool,omitempty"`
+}
+
+// TCAgentPool ...
+type TCAgentPool struct {
+	ID   int    `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// TCAgents ...
+type TCAgents struct {
+	Count int       `json:"count,omitempty"`
+	Agent []TCAgent `json:"agent,omitempty"`
+}
+
+// TCQueuedBuild is a build waiting in the build queue along with
+// the details required to understand why it is waiting
+type TCQueuedBuild struct {
+	TCBuildDetails
+	QueuePosition    int       `json:"queuePosition"` // 1 based position in the build queue
+	CompatibleAgents []TCAgent `json:"compatibleAgents,omitempty"`
+}
+
+// TCQueueQueryParams ...
+type TCQueueQueryParams struct {
 	BuildTypeID string // Pipeline name (BuildConfig ID)
+	ProjectID   string // Project ID
 	Branch      string // Branch name
-	Status      string // Status such as SUCCESS FAILURE UNKNOWN
-	User        string // Teamcity username
-	Running     bool   // Build running
-	Cancelled   bool   // Build cancelled
-	Start       uint   // Start index when listing builds
-	Count       uint   // Number of build records to return from start index
-	LookupLimit uint   // Lookup limit that limits teamcity to process the latest N builds only
+	User        string // Teamcity username of the user who triggered the build
+	Count       uint   // Number of queued builds to return
+}
+
+// TCCancelResult is the outcome of cancelling a single queued build
+type TCCancelResult struct {
+	ID  int
+	Err error
+}
+
+// TCRebuildOptions controls how an existing build is re-queued
+type TCRebuildOptions struct {
+	Branch       string            // Overrides branch of the original build when not empty
+	Comment      string            // Comment of the new build
+	Params       map[string]string // Params added to or overriding the params of the original build
+	SameRevision bool              // Build on the same VCS revisions as the original build
+	Tags         []string          // Tags added to the build when it is queued
+}
+
+// StartBuildOptions describes a build to be added to the build queue
+type StartBuildOptions struct {
+	BuildTypeID            string            // Pipeline name (BuildConfig ID)
+	Branch                 string            // Branch name
+	Comment                string            // Text comment on the build
+	Params                 map[string]string // Params/env variables to add or override
+	SnapshotDependencies   map[string]int    // Build IDs of snapshot dependencies keyed by pipeline
+	ArtifactDependencies   map[string]int    // Build IDs of artifact dependencies keyed by pipeline
+	ChangeID               int               // Build on the change with this teamcity change ID
+	Revision               string            // Build on this VCS revision, e.g. a commit SHA
+	VcsRootInstanceID      string            // VCS root instance of Revision. When empty teamcity resolves Revision among the changes of the pipeline
+	Revisions              []TCRevision      // Build on exactly these revisions, e.g. those of an earlier build. Takes precedence over ChangeID and Revision
+	Personal               bool              // Start a personal build
+	CleanSources           bool              // Clean all files in the checkout directory before the build
+	RebuildAllDependencies bool              // Rebuild all snapshot dependencies instead of reusing suitable builds
+	QueueAtTop             bool              // Put the build at the top of the build queue
+	AgentID                int               // Run the build on the agent with this ID
+	AgentName              string            // Run the build on the agent with this name
+	AgentPoolID            int               // Run the build on an agent of the pool with this ID
+	Tags                   []string          // Tags added to the build when it is queued
+}
+
+// TCTest ...
+type TCTest struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// TCTestOccurrence is the result of a test in a build
+type TCTestOccurrence struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Status         string          `json:"status"`             // SUCCESS FAILURE UNKNOWN
+	Duration       int             `json:"duration,omitempty"` // Duration in milliseconds
+	Ignored        bool            `json:"ignored,omitempty"`
+	Muted          bool            `json:"muted,omitempty"`
+	CurrentlyMuted bool            `json:"currentlyMuted,omitempty"`
+	NewFailure     bool            `json:"newFailure,omitempty"`
+	Details        string          `json:"details,omitempty"` // Failure message and stack trace
+	Test           *TCTest         `json:"test,omitempty"`
+	Build          *TCBuildDetails `json:"build,omitempty"`
+}
+
+// TCTestOccurrences ...
+type TCTestOccurrences struct {
+	Count          int                `json:"count,omitempty"`
+	NextHref       string             `json:"nextHref,omitempty"`
+	TestOccurrence []TCTestOccurrence `json:"testOccurrence"`
+}
+
+// TCTestQueryParams ...
+type TCTestQueryParams struct {
+	Status     string // Status such as SUCCESS FAILURE UNKNOWN
+	NewFailure bool   // Only tests that failed for the first time
+	Muted      bool   // Only muted tests
+	Ignored    bool   // Only ignored tests
+	Count      uint   // Maximum number of test occurrences to return, all when 0
+}
+
+// TCTestHistoryParams ...
+type TCTestHistoryParams struct {
+	BuildTypeID string // Pipeline name (BuildConfig ID)
+	Count       uint   // Number of most recent test occurrences to return
+}
+
+// Types of build problems reported by teamcity
+const (
+	ProblemCompilationError         = "TC_COMPILATION_ERROR"
+	ProblemExitCode                 = "TC_EXIT_CODE"
+	ProblemFailedTests              = "TC_FAILED_TESTS"
+	ProblemExecutionTimeout         = "TC_EXECUTION_TIMEOUT"
+	ProblemErrorMessage             = "TC_ERROR_MESSAGE"
+	ProblemUserProvided             = "TC_USER_PROVIDED"
+	ProblemSnapshotDependencyError  = "SNAPSHOT_DEPENDENCY_ERROR"
+	ProblemSnapshotDependencyFailed = "SNAPSHOT_DEPENDENCY_ERROR_BUILD_PROCEEDS_TYPE"
+	ProblemFailureOnMetric          = "BuildFailureOnMetric"
+	ProblemFailureOnMessage         = "BuildFailureOnMessage"
+)
+
+// Categories of build problems
+const (
+	ProblemCategoryCode           = "code"           // Caused by the sources being built
+	ProblemCategoryInfrastructure = "infrastructure" // Caused by agents, network or resources
+	ProblemCategoryDependency     = "dependency"     // Caused by a failed snapshot dependency
+	ProblemCategoryCustom         = "custom"         // Reported by the build script
+)
+
+// TCProblemOccurrence is a problem that caused a build to fail
+type TCProblemOccurrence struct {
+	ID             string `json:"id"`
+	Type           string `json:"type"`
+	Identity       string `json:"identity"`
+	Details        string `json:"details,omitempty"`
+	AdditionalData string `json:"additionalData,omitempty"`
+	Muted          bool   `json:"muted,omitempty"`
+	CurrentlyMuted bool   `json:"currentlyMuted,omitempty"`
+	NewFailure     bool   `json:"newFailure,omitempty"`
+}
+
+// TCProblemOccurrences ...
+type TCProblemOccurrences struct {
+	Count             int                   `json:"count,omitempty"`
+	NextHref          string                `json:"nextHref,omitempty"`
+	ProblemOccurrence []TCProblemOccurrence `json:"problemOccurrence"`
+}
+
+// TCStatisticPoint is the value of a build statistic in a build
+type TCStatisticPoint struct {
+	BuildID    int     `json:"buildId"`
+	Number     string  `json:"number,omitempty"`
+	FinishDate string  `json:"finishDate,omitempty"`
+	Value      float64 `json:"value"`
+}
+
+// TCStatisticQueryParams ...
+type TCStatisticQueryParams struct {
+	BuildTypeID string // Pipeline name (BuildConfig ID)
+	Branch      string // Branch name, default branch when empty
+	Metric      string // Name of the build statistic, e.g. BuildDuration or a custom buildStatisticValue key
+	Builds      uint   // Number of most recent builds to collect the statistic from, required
+}
+
+// TCBuildChainEdge is a snapshot dependency between two builds of a chain
+type TCBuildChainEdge struct {
+	From int `json:"from"` // ID of the build that is depended upon
+	To   int `json:"to"`   // ID of the build that depends on From
+}
+
+// TCBuildChain is the snapshot dependency graph around a build
+type TCBuildChain struct {
+	Root   int                `json:"root"`
+	Builds []TCBuildDetails   `json:"builds"`
+	Edges  []TCBuildChainEdge `json:"edges"`
+}
+
+// TCMatrixAxis is a param and the values a matrix build is run with
+type TCMatrixAxis struct {
+	Name   string   // Name of the param, e.g. env.JDK
+	Values []string // Values of the param, one build is started per value
+}
+
+// TCMatrixRun is a build of a matrix for one combination of axis values
+type TCMatrixRun struct {
+	Values  map[string]string `json:"values"` // Value of every axis keyed by axis name
+	BuildID int               `json:"buildId,omitempty"`
+	Build   *TCBuildDetails   `json:"build,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// TCCanceledInfo is present on builds that were cancelled or stopped
+type TCCanceledInfo struct {
+	User      *TCUser `json:"user,omitempty"`
+	Timestamp string  `json:"timestamp,omitempty"`
+	Text      string  `json:"text,omitempty"`
+}
+
+// TCArtifactFile is a file or directory in the artifacts of a build
+type TCArtifactFile struct {
+	Name             string           `json:"name"`
+	FullName         string           `json:"fullName,omitempty"` // Path relative to the artifacts directory
+	Size             int64            `json:"size,omitempty"`
+	ModificationTime string           `json:"modificationTime,omitempty"`
+	Children         *TCArtifactFiles `json:"children,omitempty"` // Set on directories only
+}
+
+// TCArtifactFiles ...
+type TCArtifactFiles struct {
+	Count int              `json:"count,omitempty"`
+	File  []TCArtifactFile `json:"file"`
 }
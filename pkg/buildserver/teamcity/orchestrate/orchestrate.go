@@ -0,0 +1,300 @@
+/*
+Package orchestrate runs a graph of teamcity pipelines that are not wired
+together as snapshot dependencies
+
+A flow is declared in YAML or JSON. Steps are triggered in topological
+order once all steps they depend on succeeded, and the builds of upstream
+steps listed in artifactsFrom are passed as artifact dependencies:
+
+	name: release
+	branch: master
+	steps:
+	  - name: build
+	    pipeline: Project_Build
+	  - name: deploy
+	    pipeline: Project_Deploy
+	    artifactsFrom: [build]
+	    params:
+	      env.TARGET: staging
+
+Artifact dependencies are passed to teamcity keyed by pipeline, hence the
+steps listed in artifactsFrom must run different pipelines. When a step
+fails, the steps downstream of it are skipped.
+*/
+package orchestrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+	"gopkg.in/yaml.v2"
+)
+
+// Step states reported in the result of a flow run
+const (
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateSkipped   = "skipped"
+)
+
+// Client is the subset of the teamcity client used to run flows
+type Client interface {
+	StartBuildWithOptions(ctx context.Context, options teamcity.StartBuildOptions) (int, error)
+	WaitForBuild(ctx context.Context, id int, pollInterval time.Duration) (teamcity.TCBuildDetails, error)
+}
+
+// Flow is a graph of pipelines to run
+type Flow struct {
+	Name    string `json:"name" yaml:"name"`
+	Branch  string `json:"branch" yaml:"branch"`   // Default branch of the steps
+	Comment string `json:"comment" yaml:"comment"` // Default comment of the builds
+	Steps   []Step `json:"steps" yaml:"steps"`
+}
+
+// Step is a pipeline of a flow
+type Step struct {
+	Name          string            `json:"name" yaml:"name"`
+	Pipeline      string            `json:"pipeline" yaml:"pipeline"` // Pipeline name (BuildConfig ID)
+	Branch        string            `json:"branch" yaml:"branch"`     // Overrides the branch of the flow
+	Params        map[string]string `json:"params" yaml:"params"`
+	DependsOn     []string          `json:"dependsOn" yaml:"dependsOn"`         // Steps that must succeed before this step
+	ArtifactsFrom []string          `json:"artifactsFrom" yaml:"artifactsFrom"` // Steps whose builds are used as artifact dependencies
+}
+
+// StepResult is the outcome of a step of a flow run
+type StepResult struct {
+	Step    string                   `json:"step"`
+	State   string                   `json:"state"` // succeeded, failed or skipped
+	BuildID int                      `json:"buildId,omitempty"`
+	Build   *teamcity.TCBuildDetails `json:"build,omitempty"`
+	Error   string                   `json:"error,omitempty"`
+}
+
+// Options ...
+type Options struct {
+	PollInterval time.Duration                    // Interval at which running builds are polled
+	OnEvent      func(step, state string, id int) // Called when a step is started, finishes or is skipped
+}
+
+// Load reads a flow from a YAML or JSON file
+func Load(path string) (Flow, error) {
+	var flow Flow
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return flow, err
+	}
+
+	// JSON is valid YAML, hence both are decoded the same way
+	if err := yaml.UnmarshalStrict(content, &flow); err != nil {
+		return flow, err
+	}
+	return flow, flow.Validate()
+}
+
+// Validate checks that step names are unique, that dependencies
+// refer to existing steps, that the steps a step takes artifacts
+// from run different pipelines and that the flow has no cycles
+func (f Flow) Validate() error {
+	_, err := f.Order()
+	return err
+}
+
+// Order returns the step names in topological order, upstream first
+func (f Flow) Order() ([]string, error) {
+	steps := map[string]Step{}
+	for _, step := range f.Steps {
+		if step.Name == "" || step.Pipeline == "" {
+			return nil, errors.New("every step of a flow needs a name and a pipeline")
+		}
+		if _, ok := steps[step.Name]; ok {
+			return nil, fmt.Errorf("step %s is declared more than once", step.Name)
+		}
+		steps[step.Name] = step
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := map[string]int{}
+	order := []string{}
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch marks[name] {
+		case visiting:
+			return fmt.Errorf("steps form a cycle: %v", append(path, name))
+		case visited:
+			return nil
+		}
+
+		marks[name] = visiting
+		for _, upstream := range steps[name].upstream() {
+			if _, ok := steps[upstream]; !ok {
+				return fmt.Errorf("step %s depends on unknown step %s", name, upstream)
+			}
+			if err := visit(upstream, append(path, name)); err != nil {
+				return err
+			}
+		}
+
+		// Artifact dependencies are passed to teamcity keyed by pipeline
+		artifactSteps := map[string]string{}
+		for _, upstream := range steps[name].ArtifactsFrom {
+			pipeline := steps[upstream].Pipeline
+			if other, ok := artifactSteps[pipeline]; ok && other != upstream {
+				return fmt.Errorf(
+					"step %s takes artifacts from steps %s and %s of the same pipeline %s",
+					name, other, upstream, pipeline)
+			}
+			artifactSteps[pipeline] = upstream
+		}
+		marks[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, step := range f.Steps {
+		if err := visit(step.Name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// upstream returns the steps this step waits for
+func (s Step) upstream() []string {
+	return append(append([]string{}, s.DependsOn...), s.ArtifactsFrom...)
+}
+
+// Run triggers the steps of the flow once the steps they depend on have
+// succeeded, waits for their builds to finish and skips the steps
+// downstream of a failed step. Results are returned in topological order
+func Run(ctx context.Context, client Client, flow Flow, options Options) ([]StepResult, error) {
+	order, err := flow.Order()
+	if err != nil {
+		return nil, err
+	}
+
+	notify := options.OnEvent
+	if notify == nil {
+		notify = func(string, string, int) {}
+	}
+
+	steps := map[string]Step{}
+	done := map[string]chan struct{}{}
+	for _, step := range flow.Steps {
+		steps[step.Name] = step
+		done[step.Name] = make(chan struct{})
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = map[string]*StepResult{}
+	)
+	for _, name := range order {
+		results[name] = &StepResult{Step: name}
+	}
+
+	for _, name := range order {
+		wg.Add(1)
+		go func(step Step) {
+			defer wg.Done()
+			defer close(done[step.Name])
+
+			result := results[step.Name]
+			artifactDependencies := map[string]int{}
+
+			for _, upstream := range step.upstream() {
+				<-done[upstream]
+			}
+
+			mu.Lock()
+			for _, upstream := range step.upstream() {
+				if results[upstream].State != StateSucceeded {
+					result.State = StateSkipped
+					result.Error = fmt.Sprintf("upstream step %s %s", upstream, results[upstream].State)
+				}
+			}
+			for _, upstream := range step.ArtifactsFrom {
+				artifactDependencies[steps[upstream].Pipeline] = results[upstream].BuildID
+			}
+			mu.Unlock()
+
+			if result.State == StateSkipped {
+				notify(step.Name, StateSkipped, 0)
+				return
+			}
+
+			state, id, build, err := runStep(ctx, client, flow, step, artifactDependencies, options.PollInterval, notify)
+
+			mu.Lock()
+			result.State = state
+			result.BuildID = id
+			result.Build = build
+			if err != nil {
+				result.Error = err.Error()
+			}
+			mu.Unlock()
+
+			notify(step.Name, state, id)
+		}(steps[name])
+	}
+	wg.Wait()
+
+	ordered := make([]StepResult, 0, len(order))
+	for _, name := range order {
+		ordered = append(ordered, *results[name])
+	}
+	return ordered, nil
+}
+
+// runStep triggers the build of a step and waits for it to finish
+func runStep(
+	ctx context.Context,
+	client Client,
+	flow Flow,
+	step Step,
+	artifactDependencies map[string]int,
+	pollInterval time.Duration,
+	notify func(step, state string, id int)) (string, int, *teamcity.TCBuildDetails, error) {
+	branch := step.Branch
+	if branch == "" {
+		branch = flow.Branch
+	}
+
+	comment := flow.Comment
+	if comment == "" {
+		comment = fmt.Sprintf("Step %s of flow %s", step.Name, flow.Name)
+	}
+
+	id, err := client.StartBuildWithOptions(ctx, teamcity.StartBuildOptions{
+		BuildTypeID:          step.Pipeline,
+		Branch:               branch,
+		Comment:              comment,
+		Params:               step.Params,
+		ArtifactDependencies: artifactDependencies,
+	})
+	if err != nil {
+		return StateFailed, 0, nil, err
+	}
+	notify(step.Name, "started", id)
+
+	build, err := client.WaitForBuild(ctx, id, pollInterval)
+	if err != nil {
+		return StateFailed, id, nil, err
+	}
+
+	if build.Status != "SUCCESS" {
+		return StateFailed, id, &build, fmt.Errorf("build %d finished with status %s: %s", id, build.Status, build.StatusText)
+	}
+	return StateSucceeded, id, &build, nil
+}
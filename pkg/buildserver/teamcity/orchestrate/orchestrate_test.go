@@ -0,0 +1,241 @@
+package orchestrate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raghuP9/buildserver-client/pkg/buildserver/teamcity"
+)
+
+// fakeClient starts builds with increasing ids that finish
+// with the status configured for their pipeline
+type fakeClient struct {
+	mu       sync.Mutex
+	statuses map[string]string // Status keyed by pipeline, SUCCESS when missing
+	started  []teamcity.StartBuildOptions
+	builds   map[int]string // Pipeline keyed by build id
+}
+
+func (c *fakeClient) StartBuildWithOptions(ctx context.Context, options teamcity.StartBuildOptions) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.builds == nil {
+		c.builds = map[int]string{}
+	}
+	c.started = append(c.started, options)
+	id := len(c.started)
+	c.builds[id] = options.BuildTypeID
+	return id, nil
+}
+
+func (c *fakeClient) WaitForBuild(ctx context.Context, id int, pollInterval time.Duration) (teamcity.TCBuildDetails, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := c.statuses[c.builds[id]]
+	if status == "" {
+		status = "SUCCESS"
+	}
+	return teamcity.TCBuildDetails{ID: id, BuildTypeID: c.builds[id], State: "finished", Status: status}, nil
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "orchestrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name: "flow.yaml",
+			content: `name: release
+branch: master
+steps:
+  - name: build
+    pipeline: Project_Build
+  - name: deploy
+    pipeline: Project_Deploy
+    artifactsFrom: [build]
+    params:
+      env.TARGET: staging
+`,
+		},
+		{
+			name:    "flow.json",
+			content: `{"name": "release", "steps": [{"name": "build", "pipeline": "Project_Build"}, {"name": "deploy", "pipeline": "Project_Deploy", "artifactsFrom": ["build"], "params": {"env.TARGET": "staging"}}]}`,
+		},
+		{
+			name:    "unknown-field.yaml",
+			content: "name: release\nstepz: []\n",
+			err:     "stepz",
+		},
+		{
+			name:    "cycle.yaml",
+			content: "steps:\n  - {name: a, pipeline: A, dependsOn: [b]}\n  - {name: b, pipeline: B, dependsOn: [a]}\n",
+			err:     "cycle",
+		},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(dir, test.name)
+		if err := ioutil.WriteFile(path, []byte(test.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		flow, err := Load(path)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want an error about %s", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if flow.Name != "release" || len(flow.Steps) != 2 || flow.Steps[1].ArtifactsFrom[0] != "build" ||
+			flow.Steps[1].Params["env.TARGET"] != "staging" {
+			t.Errorf("%s: unexpected flow %+v", test.name, flow)
+		}
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []Step
+		want  []string
+		err   string
+	}{
+		{
+			name: "diamond",
+			steps: []Step{
+				{Name: "deploy", Pipeline: "D", DependsOn: []string{"test"}, ArtifactsFrom: []string{"package"}},
+				{Name: "test", Pipeline: "T", DependsOn: []string{"build"}},
+				{Name: "package", Pipeline: "P", DependsOn: []string{"build"}},
+				{Name: "build", Pipeline: "B"},
+			},
+			want: []string{"build", "test", "package", "deploy"},
+		},
+		{
+			name:  "cycle",
+			steps: []Step{{Name: "a", Pipeline: "A", DependsOn: []string{"b"}}, {Name: "b", Pipeline: "B", ArtifactsFrom: []string{"a"}}},
+			err:   "steps form a cycle: [a b a]",
+		},
+		{
+			name:  "self dependency",
+			steps: []Step{{Name: "a", Pipeline: "A", DependsOn: []string{"a"}}},
+			err:   "steps form a cycle",
+		},
+		{
+			name:  "unknown step",
+			steps: []Step{{Name: "a", Pipeline: "A", ArtifactsFrom: []string{"missing"}}},
+			err:   "step a depends on unknown step missing",
+		},
+		{
+			name:  "duplicate step",
+			steps: []Step{{Name: "a", Pipeline: "A"}, {Name: "a", Pipeline: "B"}},
+			err:   "step a is declared more than once",
+		},
+		{
+			name:  "missing pipeline",
+			steps: []Step{{Name: "a"}},
+			err:   "needs a name and a pipeline",
+		},
+		{
+			name: "artifacts from the same pipeline twice",
+			steps: []Step{
+				{Name: "linux", Pipeline: "Build"},
+				{Name: "windows", Pipeline: "Build"},
+				{Name: "release", Pipeline: "Release", ArtifactsFrom: []string{"linux", "windows"}},
+			},
+			err: "step release takes artifacts from steps linux and windows of the same pipeline Build",
+		},
+	}
+
+	for _, test := range tests {
+		order, err := Flow{Steps: test.steps}.Order()
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if strings.Join(order, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s: got order %v, want %v", test.name, order, test.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	flow := Flow{
+		Name:   "release",
+		Branch: "master",
+		Steps: []Step{
+			{Name: "build", Pipeline: "B"},
+			{Name: "test", Pipeline: "T", DependsOn: []string{"build"}},
+			{Name: "deploy", Pipeline: "D", Branch: "prod", ArtifactsFrom: []string{"build", "test"}},
+			{Name: "lint", Pipeline: "L"},
+			{Name: "notify", Pipeline: "N", DependsOn: []string{"lint"}},
+		},
+	}
+	client := &fakeClient{statuses: map[string]string{"L": "FAILURE"}}
+
+	results, err := Run(context.Background(), client, flow, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states := map[string]StepResult{}
+	for _, result := range results {
+		states[result.Step] = result
+	}
+	for step, want := range map[string]string{
+		"build":  StateSucceeded,
+		"test":   StateSucceeded,
+		"deploy": StateSucceeded,
+		"lint":   StateFailed,
+		"notify": StateSkipped,
+	} {
+		if states[step].State != want {
+			t.Errorf("step %s is %s, want %s", step, states[step].State, want)
+		}
+	}
+
+	for _, options := range client.started {
+		if options.BuildTypeID != "D" {
+			continue
+		}
+		want := map[string]int{"B": states["build"].BuildID, "T": states["test"].BuildID}
+		if len(options.ArtifactDependencies) != 2 ||
+			options.ArtifactDependencies["B"] != want["B"] || options.ArtifactDependencies["T"] != want["T"] {
+			t.Errorf("deploy got artifact dependencies %v, want %v", options.ArtifactDependencies, want)
+		}
+		if options.Branch != "prod" || options.Comment != "Step deploy of flow release" {
+			t.Errorf("deploy started on branch %s with comment %q", options.Branch, options.Comment)
+		}
+	}
+	if len(client.started) != 4 {
+		t.Errorf("got %d builds started, want 4", len(client.started))
+	}
+}
@@ -0,0 +1,126 @@
+package teamcity
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// defaultPageSize is the number of builds requested per page
+// when paging through builds without an explicit count
+const defaultPageSize = 100
+
+// BuildLister lists a page of builds as per the query params. It is
+// implemented by TCClient
+type BuildLister interface {
+	ListBuildsPage(ctx context.Context, params TCQueryParams) (TCBuildSnapshotDependencies, error)
+}
+
+// ListBuildsPage returns one page of the builds matching the query params,
+// params.Start being the index of the first build and params.Count the
+// page size. Unlike GetAllBuilds, values such as branch names and tags
+// are escaped and an error is returned for non 2xx responses
+func (t *TCClient) ListBuildsPage(ctx context.Context, params TCQueryParams) (TCBuildSnapshotDependencies, error) {
+	path := fmt.Sprintf("/app/rest/builds?locator=%s", url.QueryEscape(buildLocator(params)))
+	if params.Fields != "" {
+		path = fmt.Sprintf("%s&fields=count,build(%s)", path, params.Fields)
+	}
+
+	var builds TCBuildSnapshotDependencies
+	err := t.doRequest(ctx, "GET", path, nil, &builds)
+	return builds, err
+}
+
+// buildLocator returns the build locator of the query params
+func buildLocator(params TCQueryParams) string {
+	locator := []string{}
+
+	if params.BuildTypeID != "" {
+		locator = append(locator, fmt.Sprintf("buildType:(id:%s)", locatorValue(params.BuildTypeID)))
+	}
+
+	if params.Branch != "" {
+		locator = append(locator, fmt.Sprintf("branch:(name:%s)", locatorValue(params.Branch)))
+	} else if params.AnyBranch {
+		locator = append(locator, "branch:default:any")
+	}
+
+	if params.Status != "" {
+		locator = append(locator, fmt.Sprintf("status:%s", params.Status))
+	}
+
+	if params.User != "" {
+		locator = append(locator, fmt.Sprintf("user:(username:%s)", locatorValue(params.User)))
+	}
+
+	if params.Count > 0 {
+		locator = append(locator, fmt.Sprintf("count:%d", params.Count))
+	}
+
+	if params.Start > 0 {
+		locator = append(locator, fmt.Sprintf("start:%d", params.Start))
+	}
+
+	if params.LookupLimit > 0 {
+		locator = append(locator, fmt.Sprintf("lookupLimit:%d", params.LookupLimit))
+	}
+
+	for _, tag := range params.Tags {
+		locator = append(locator, fmt.Sprintf("tag:%s", locatorValue(tag)))
+	}
+
+	if params.SinceBuild > 0 {
+		locator = append(locator, fmt.Sprintf("sinceBuild:(id:%d)", params.SinceBuild))
+	}
+
+	if !params.SinceDate.IsZero() {
+		locator = append(locator, fmt.Sprintf("sinceDate:%s", params.SinceDate.Format(TimeFormat)))
+	}
+
+	if params.Pinned {
+		locator = append(locator, "pinned:true")
+	}
+
+	if params.AnyRunning {
+		locator = append(locator, "running:any")
+	} else if params.Running {
+		locator = append(locator, "running:true")
+	}
+
+	if params.AnyCancelled {
+		locator = append(locator, "canceled:any")
+	} else if params.Cancelled {
+		locator = append(locator, "canceled:true")
+	}
+
+	return strings.Join(locator, ",")
+}
+
+// ListBuilds pages through the builds matching the query params, newest
+// first, until limit builds are read or no builds are left. All matching
+// builds are returned when limit is 0. params.Count is used as the page
+// size and params.Start as the index of the first build
+func ListBuilds(ctx context.Context, lister BuildLister, params TCQueryParams, limit uint) ([]TCBuildDetails, error) {
+	if params.Count == 0 {
+		params.Count = defaultPageSize
+	}
+
+	builds := []TCBuildDetails{}
+	for {
+		if limit > 0 && limit-uint(len(builds)) < params.Count {
+			params.Count = limit - uint(len(builds))
+		}
+
+		page, err := lister.ListBuildsPage(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		builds = append(builds, page.Builds...)
+
+		if uint(len(page.Builds)) < params.Count || (limit > 0 && uint(len(builds)) >= limit) {
+			return builds, nil
+		}
+		params.Start += uint(len(page.Builds))
+	}
+}
@@ -0,0 +1,113 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client of a teamcity stand-in served by
+// handler. The server is to be closed by the caller
+func newTestClient(handler http.HandlerFunc) (*TCClient, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	return NewTeamcityClient(5*time.Second, 5*time.Second, 5*time.Second, server.URL, "token", false), server
+}
+
+func TestBuildLocator(t *testing.T) {
+	tests := []struct {
+		params TCQueryParams
+		want   string
+	}{
+		{TCQueryParams{}, ""},
+		{
+			TCQueryParams{BuildTypeID: "P1", Status: "FAILURE", Count: 10, Start: 20},
+			"buildType:(id:" + locatorValue("P1") + "),status:FAILURE,count:10,start:20",
+		},
+		{
+			TCQueryParams{Branch: "feature/a,b:(c)", Tags: []string{"x,y", "z"}},
+			"branch:(name:" + locatorValue("feature/a,b:(c)") + "),tag:" + locatorValue("x,y") + ",tag:" + locatorValue("z"),
+		},
+		{
+			TCQueryParams{User: "jdoe", Running: true, Cancelled: true, Pinned: true},
+			"user:(username:" + locatorValue("jdoe") + "),pinned:true,running:true,canceled:true",
+		},
+		{
+			TCQueryParams{AnyBranch: true, Running: true, AnyRunning: true, Cancelled: true, AnyCancelled: true},
+			"branch:default:any,running:any,canceled:any",
+		},
+		{
+			TCQueryParams{Branch: "main", AnyBranch: true},
+			"branch:(name:" + locatorValue("main") + ")",
+		},
+	}
+
+	for _, test := range tests {
+		if got := buildLocator(test.params); got != test.want {
+			t.Errorf("buildLocator(%+v) = %q, want %q", test.params, got, test.want)
+		}
+	}
+}
+
+func TestListBuilds(t *testing.T) {
+	const total = 7
+
+	var locators []string
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		locator := r.URL.Query().Get("locator")
+		locators = append(locators, locator)
+
+		var start, count int
+		for _, dimension := range strings.Split(locator, ",") {
+			fmt.Sscanf(dimension, "start:%d", &start)
+			fmt.Sscanf(dimension, "count:%d", &count)
+		}
+
+		page := TCBuildSnapshotDependencies{}
+		for id := total - start; id > 0 && len(page.Builds) < count; id-- {
+			page.Builds = append(page.Builds, TCBuildDetails{ID: id})
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+	defer server.Close()
+
+	tests := []struct {
+		count, limit uint
+		want         int
+		requests     int
+	}{
+		{count: 3, limit: 0, want: 7, requests: 3},
+		{count: 3, limit: 5, want: 5, requests: 2},
+		{count: 0, limit: 2, want: 2, requests: 1},
+		{count: 7, limit: 0, want: 7, requests: 2},
+	}
+
+	for _, test := range tests {
+		locators = nil
+		builds, err := ListBuilds(context.Background(), client, TCQueryParams{Count: test.count}, test.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(builds) != test.want || builds[0].ID != total {
+			t.Errorf("count %d limit %d: got %d builds, want %d newest first", test.count, test.limit, len(builds), test.want)
+		}
+		if len(locators) != test.requests {
+			t.Errorf("count %d limit %d: got requests %q, want %d", test.count, test.limit, locators, test.requests)
+		}
+	}
+}
+
+func TestListBuildsError(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such build type", http.StatusNotFound)
+	})
+	defer server.Close()
+
+	if _, err := ListBuilds(context.Background(), client, TCQueryParams{BuildTypeID: "missing"}, 0); err == nil {
+		t.Fatal("expected an error for a 404 response")
+	}
+}
@@ -0,0 +1,78 @@
+package teamcity
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// infrastructureMarkers are substrings of problem details
+// that point to a failure of the build infrastructure
+var infrastructureMarkers = []string{
+	"agent disconnected",
+	"agent was disconnected",
+	"outofmemory",
+	"out of memory",
+	"no space left on device",
+	"connection reset",
+	"connection refused",
+	"failed to start build",
+}
+
+// infrastructureText reports whether text, e.g. the details of a problem
+// or the status text of a build, contains an infrastructure marker
+func infrastructureText(text string) bool {
+	text = strings.ToLower(text)
+	for _, marker := range infrastructureMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListProblemOccurrences returns the problems that caused a build to fail
+func (t *TCClient) ListProblemOccurrences(ctx context.Context, buildID int) ([]TCProblemOccurrence, error) {
+	path := fmt.Sprintf(
+		"/app/rest/problemOccurrences?locator=%s"+
+			"&fields=count,nextHref,problemOccurrence(id,type,identity,details,additionalData,muted,currentlyMuted,newFailure)",
+		url.QueryEscape(fmt.Sprintf("build:(id:%d)", buildID)))
+
+	problems := []TCProblemOccurrence{}
+	for path != "" {
+		var page TCProblemOccurrences
+		if err := t.doRequest(ctx, "GET", path, nil, &page); err != nil {
+			return nil, err
+		}
+		problems = append(problems, page.ProblemOccurrence...)
+		path = page.NextHref
+	}
+
+	return problems, nil
+}
+
+// Category classifies the problem as a code, infrastructure,
+// dependency or custom problem
+func (p TCProblemOccurrence) Category() string {
+	if infrastructureText(p.Details) {
+		return ProblemCategoryInfrastructure
+	}
+
+	switch p.Type {
+	case ProblemExecutionTimeout:
+		return ProblemCategoryInfrastructure
+	case ProblemSnapshotDependencyError, ProblemSnapshotDependencyFailed:
+		return ProblemCategoryDependency
+	case ProblemUserProvided, ProblemErrorMessage, ProblemFailureOnMessage:
+		return ProblemCategoryCustom
+	default:
+		return ProblemCategoryCode
+	}
+}
+
+// Infrastructure reports whether the problem was caused by
+// the build infrastructure rather than the sources
+func (p TCProblemOccurrence) Infrastructure() bool {
+	return p.Category() == ProblemCategoryInfrastructure
+}
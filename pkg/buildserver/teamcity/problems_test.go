@@ -0,0 +1,53 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestListProblemOccurrences(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		page := TCProblemOccurrences{}
+		switch r.URL.Query().Get("locator") {
+		case "build:(id:42)":
+			page.ProblemOccurrence = []TCProblemOccurrence{{ID: "1", Type: ProblemCompilationError}}
+			page.NextHref = "/app/rest/problemOccurrences?locator=build:(id:42),start:1"
+		case "build:(id:42),start:1":
+			page.ProblemOccurrence = []TCProblemOccurrence{{ID: "2", Type: ProblemExitCode}}
+		default:
+			http.Error(w, "unexpected locator", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+	defer server.Close()
+
+	problems, err := client.ListProblemOccurrences(context.Background(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 || problems[0].ID != "1" || problems[1].ID != "2" {
+		t.Errorf("got problems %+v, want both pages", problems)
+	}
+}
+
+func TestProblemCategory(t *testing.T) {
+	tests := []struct {
+		problem TCProblemOccurrence
+		want    string
+	}{
+		{TCProblemOccurrence{Type: ProblemCompilationError}, ProblemCategoryCode},
+		{TCProblemOccurrence{Type: ProblemExitCode, Details: "Agent was disconnected"}, ProblemCategoryInfrastructure},
+		{TCProblemOccurrence{Type: ProblemExecutionTimeout}, ProblemCategoryInfrastructure},
+		{TCProblemOccurrence{Type: ProblemSnapshotDependencyFailed}, ProblemCategoryDependency},
+		{TCProblemOccurrence{Type: ProblemUserProvided}, ProblemCategoryCustom},
+	}
+
+	for _, test := range tests {
+		if got := test.problem.Category(); got != test.want {
+			t.Errorf("%+v: got category %s, want %s", test.problem, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package teamcity
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const queuedBuildFields = "id,buildTypeId,state,branchName,webUrl,waitReason,queuedDate,startEstimate,comment(text)"
+
+// ListQueuedBuilds returns the builds currently waiting in the build queue
+// that match the query params, in the order they will be started
+func (t *TCClient) ListQueuedBuilds(ctx context.Context, params TCQueueQueryParams) ([]TCQueuedBuild, error) {
+	var locator []string
+
+	if params.BuildTypeID != "" {
+		locator = append(locator, fmt.Sprintf("buildType:(id:%s)", params.BuildTypeID))
+	}
+
+	if params.ProjectID != "" {
+		locator = append(locator, fmt.Sprintf("project:(id:%s)", params.ProjectID))
+	}
+
+	if params.User != "" {
+		locator = append(locator, fmt.Sprintf("user:%s", params.User))
+	}
+
+	path := fmt.Sprintf("/app/rest/buildQueue?fields=count,build(%s)", queuedBuildFields)
+	if len(locator) > 0 {
+		path = fmt.Sprintf("%s&locator=%s", path, url.QueryEscape(strings.Join(locator, ",")))
+	}
+
+	var queued TCBuildSnapshotDependencies
+	if err := t.doRequest(ctx, "GET", path, nil, &queued); err != nil {
+		return nil, err
+	}
+
+	order, err := t.queueOrder(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	builds := []TCQueuedBuild{}
+	for _, build := range queued.Builds {
+		// The queue locator has no branch dimension, hence branch
+		// is filtered on the client side
+		if params.Branch != "" && build.BranchName != params.Branch {
+			continue
+		}
+		builds = append(builds, TCQueuedBuild{
+			TCBuildDetails: build,
+			QueuePosition:  order[build.ID],
+		})
+		if params.Count > 0 && uint(len(builds)) >= params.Count {
+			break
+		}
+	}
+
+	return builds, nil
+}
+
+// GetQueuedBuild returns the details of a queued build including the reason
+// it is waiting, its position in the queue, the estimated start time and
+// the agents that are able to run it
+func (t *TCClient) GetQueuedBuild(ctx context.Context, id int) (TCQueuedBuild, error) {
+	var build TCQueuedBuild
+
+	err := t.doRequest(
+		ctx,
+		"GET",
+		fmt.Sprintf("/app/rest/buildQueue/id:%d?fields=%s", id, queuedBuildFields),
+		nil,
+		&build.TCBuildDetails)
+	if err != nil {
+		return build, err
+	}
+
+	order, err := t.queueOrder(ctx)
+	if err != nil {
+		return build, err
+	}
+	build.QueuePosition = order[id]
+
+	var agents TCAgents
+	err = t.doRequest(
+		ctx,
+		"GET",
+		fmt.Sprintf(
+			"/app/rest/agents?locator=%s&fields=agent(id,name,typeId,connected,enabled,authorized,webUrl)",
+			url.QueryEscape(fmt.Sprintf("compatible:(build:(id:%d))", id))),
+		nil,
+		&agents)
+	if err != nil {
+		return build, err
+	}
+	build.CompatibleAgents = agents.Agent
+
+	return build, nil
+}
+
+// MoveQueuedBuildToTop moves a queued build to the top of the
+// build queue so that it is the next one to be started
+func (t *TCClient) MoveQueuedBuildToTop(ctx context.Context, id int) error {
+	return t.doRequest(ctx, "PUT", "/app/rest/buildQueue/order/1", map[string]int{"id": id}, nil)
+}
+
+// queueOrder returns the 1 based position of every queued build
+// keyed by build id
+func (t *TCClient) queueOrder(ctx context.Context) (map[int]int, error) {
+	var queued TCBuildSnapshotDependencies
+	if err := t.doRequest(ctx, "GET", "/app/rest/buildQueue?fields=build(id)", nil, &queued); err != nil {
+		return nil, err
+	}
+
+	order := make(map[int]int, len(queued.Builds))
+	for i, build := range queued.Builds {
+		order[build.ID] = i + 1
+	}
+	return order, nil
+}
+
+// maxConcurrentCancels limits the number of cancel requests
+// that are made to teamcity in parallel
+const maxConcurrentCancels = 8
+
+// CancelQueuedBuilds cancels the queued builds with the provided ids
+// concurrently. It returns the outcome for every id in the order the
+// ids were provided
+func (t *TCClient) CancelQueuedBuilds(ctx context.Context, ids []int, comment string) []TCCancelResult {
+	payload := TCBuildStopPayload{
+		Comment:        comment,
+		ReaddIntoQueue: "false",
+	}
+
+	results := make([]TCCancelResult, len(ids))
+	sem := make(chan struct{}, maxConcurrentCancels)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i, id int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = TCCancelResult{
+				ID:  id,
+				Err: t.doRequest(ctx, "POST", fmt.Sprintf("/app/rest/buildQueue/id:%d", id), payload, nil),
+			}
+		}(i, id)
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,163 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// queuedBuilds are the builds in the queue of the stand-in, in queue order
+var queuedBuilds = []TCBuildDetails{
+	{ID: 3, BuildTypeID: "P1", BranchName: "main", WaitReason: "No compatible agents"},
+	{ID: 1, BuildTypeID: "P1", BranchName: "feature"},
+	{ID: 2, BuildTypeID: "P1", BranchName: "main"},
+}
+
+func TestListQueuedBuilds(t *testing.T) {
+	var locator, fields string
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/app/rest/buildQueue" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("fields") != "build(id)" {
+			locator, fields = r.URL.Query().Get("locator"), r.URL.Query().Get("fields")
+		}
+		json.NewEncoder(w).Encode(TCBuildSnapshotDependencies{Builds: queuedBuilds})
+	})
+	defer server.Close()
+
+	builds, err := client.ListQueuedBuilds(context.Background(), TCQueueQueryParams{
+		BuildTypeID: "P1",
+		ProjectID:   "Project",
+		User:        "jdoe",
+		Branch:      "main",
+		Count:       1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 1 || builds[0].ID != 3 || builds[0].QueuePosition != 1 || builds[0].WaitReason != "No compatible agents" {
+		t.Errorf("got builds %+v, want the first queued build of main", builds)
+	}
+	if locator != "buildType:(id:P1),project:(id:Project),user:jdoe" {
+		t.Errorf("got locator %q", locator)
+	}
+	if !strings.HasPrefix(fields, "count,build(") || !strings.Contains(fields, "waitReason") {
+		t.Errorf("got fields %q", fields)
+	}
+
+	builds, err = client.ListQueuedBuilds(context.Background(), TCQueueQueryParams{Branch: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	positions := []int{}
+	for _, build := range builds {
+		positions = append(positions, build.QueuePosition)
+	}
+	if !reflect.DeepEqual(positions, []int{1, 3}) || locator != "" {
+		t.Errorf("got positions %v and locator %q, want the builds of main without locator", positions, locator)
+	}
+}
+
+func TestGetQueuedBuild(t *testing.T) {
+	var agentLocator string
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/buildQueue/id:2":
+			json.NewEncoder(w).Encode(queuedBuilds[2])
+		case "/app/rest/buildQueue":
+			json.NewEncoder(w).Encode(TCBuildSnapshotDependencies{Builds: queuedBuilds})
+		case "/app/rest/agents":
+			agentLocator = r.URL.Query().Get("locator")
+			json.NewEncoder(w).Encode(TCAgents{Agent: []TCAgent{{ID: 7, Name: "agent-7"}}})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	build, err := client.GetQueuedBuild(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if build.ID != 2 || build.QueuePosition != 3 || len(build.CompatibleAgents) != 1 || build.CompatibleAgents[0].Name != "agent-7" {
+		t.Errorf("got build %+v", build)
+	}
+	if agentLocator != "compatible:(build:(id:2))" {
+		t.Errorf("got agent locator %q", agentLocator)
+	}
+
+	if _, err := client.GetQueuedBuild(context.Background(), 4); err == nil {
+		t.Error("expected an error for a build that is not queued")
+	}
+}
+
+func TestMoveQueuedBuildToTop(t *testing.T) {
+	var method, path string
+	var payload map[string]int
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		json.NewDecoder(r.Body).Decode(&payload)
+	})
+	defer server.Close()
+
+	if err := client.MoveQueuedBuildToTop(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if method != "PUT" || path != "/app/rest/buildQueue/order/1" || payload["id"] != 2 {
+		t.Errorf("got %s %s with payload %v", method, path, payload)
+	}
+}
+
+func TestCancelQueuedBuilds(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		payloads = map[string]TCBuildStopPayload{}
+	)
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		var payload TCBuildStopPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		payloads[r.Method+" "+r.URL.Path] = payload
+		if r.URL.Path == "/app/rest/buildQueue/id:2" {
+			http.Error(w, "build 2 has already started", http.StatusConflict)
+		}
+	})
+	defer server.Close()
+
+	ids := []int{}
+	for id := 1; id <= 2*maxConcurrentCancels; id++ {
+		ids = append(ids, id)
+	}
+	results := client.CancelQueuedBuilds(context.Background(), ids, "not needed")
+
+	if len(results) != len(ids) || len(payloads) != len(ids) {
+		t.Fatalf("got %d results and %d requests, want %d", len(results), len(payloads), len(ids))
+	}
+	for i, result := range results {
+		if result.ID != ids[i] {
+			t.Errorf("result %d is of build %d, want the order of the ids", i, result.ID)
+		}
+		if (result.Err != nil) != (result.ID == 2) {
+			t.Errorf("build %d: got error %v", result.ID, result.Err)
+		}
+	}
+	if !strings.Contains(results[1].Err.Error(), "already started") {
+		t.Errorf("got error %v, want the message of teamcity", results[1].Err)
+	}
+
+	payload := payloads["POST /app/rest/buildQueue/id:5"]
+	if payload != (TCBuildStopPayload{Comment: "not needed", ReaddIntoQueue: "false"}) {
+		t.Errorf("got payload %+v", payload)
+	}
+}
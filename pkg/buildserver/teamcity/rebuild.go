@@ -0,0 +1,98 @@
+package teamcity
+
+import (
+	"context"
+	"fmt"
+)
+
+/*
+RebuildBuild re-queues an existing build with identical inputs
+
+id is the build id of the build to rebuild
+
+The new build uses the pipeline, branch, params, snapshot dependencies and
+artifact dependencies of the original build. options can override the
+branch and params and request the build on the same VCS revisions.
+
+It returns the id of the queued build
+*/
+func (t *TCClient) RebuildBuild(ctx context.Context, id int, options TCRebuildOptions) (int, error) {
+	original, err := t.GetBuildDetails(ctx, id)
+	if err != nil {
+		return -1, err
+	}
+
+	payload := rebuildPayload(original, options)
+
+	buildDetails, err := t.queueBuild(ctx, payload)
+	if err != nil {
+		return -1, err
+	}
+	return buildDetails.ID, nil
+}
+
+// rebuildPayload creates the trigger payload that re-queues
+// the original build with the provided options applied
+func rebuildPayload(original TCBuildDetails, options TCRebuildOptions) TCBuildPayload {
+	payload := TCBuildPayload{
+		BuildType: TCBuildType{
+			ID: original.BuildTypeID,
+		},
+		Comment: TCBuildComment{
+			Text: options.Comment,
+		},
+		Properties: TCBuildProperties{
+			Property: []TCBuildProperty{},
+		},
+		Personal:   "false",
+		BranchName: original.BranchName,
+	}
+
+	if payload.Comment.Text == "" {
+		payload.Comment.Text = fmt.Sprintf("Rebuild of build %d", original.ID)
+	}
+
+	if options.Branch != "" {
+		payload.BranchName = options.Branch
+	}
+
+	// Keep the params of the original build that are not overridden
+	for _, property := range original.Properties.Property {
+		if _, ok := options.Params[property.Name]; !ok {
+			payload.Properties.Property = append(payload.Properties.Property, property)
+		}
+	}
+	for k, v := range options.Params {
+		payload.Properties.Property = append(payload.Properties.Property, TCBuildProperty{k, v})
+	}
+
+	payload.SnapshotDependencies = dependencyReferences(original.SnapshotDependencies)
+	payload.ArtifactDependencies = dependencyReferences(original.ArtifactDependencies)
+
+	if options.SameRevision && original.Revisions != nil && len(original.Revisions.Revision) > 0 {
+		payload.Revisions = original.Revisions
+	}
+
+	if len(options.Tags) > 0 {
+		tags := newTCTags(options.Tags)
+		payload.Tags = &tags
+	}
+
+	return payload
+}
+
+// dependencyReferences strips dependency builds down to
+// the id and build type id that teamcity expects on trigger
+func dependencyReferences(dependencies *TCBuildSnapshotDependencies) *TCBuildSnapshotDependencies {
+	if dependencies == nil || len(dependencies.Builds) == 0 {
+		return nil
+	}
+
+	references := TCBuildSnapshotDependencies{
+		Builds: []TCBuildDetails{},
+	}
+	for _, build := range dependencies.Builds {
+		references.Builds = append(references.Builds, TCBuildDetails{ID: build.ID, BuildTypeID: build.BuildTypeID})
+	}
+	return &references
+}
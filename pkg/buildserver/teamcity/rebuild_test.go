@@ -0,0 +1,133 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// originalBuild is a build with params, dependencies and revisions
+var originalBuild = TCBuildDetails{
+	ID:          10,
+	BuildTypeID: "P1",
+	BranchName:  "main",
+	Properties: TCBuildProperties{Property: []TCBuildProperty{
+		{Name: "env.A", Value: "1"},
+		{Name: "env.B", Value: "2"},
+	}},
+	Revisions: &TCRevisions{Revision: []TCRevision{{Version: "abc", VcsRootInstance: &TCVcsRootInstance{ID: "1"}}}},
+	SnapshotDependencies: &TCBuildSnapshotDependencies{Builds: []TCBuildDetails{
+		{ID: 8, BuildTypeID: "Lib", Status: "SUCCESS", WebURL: "http://teamcity/8"},
+	}},
+	ArtifactDependencies: &TCBuildSnapshotDependencies{Builds: []TCBuildDetails{
+		{ID: 9, BuildTypeID: "Assets", Number: "42"},
+	}},
+}
+
+func TestRebuildPayload(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       TCRebuildOptions
+		wantBranch    string
+		wantComment   string
+		wantParams    map[string]string
+		wantRevisions bool
+		wantTags      []string
+	}{
+		{
+			name:        "identical inputs",
+			wantBranch:  "main",
+			wantComment: "Rebuild of build 10",
+			wantParams:  map[string]string{"env.A": "1", "env.B": "2"},
+		},
+		{
+			name: "overrides",
+			options: TCRebuildOptions{
+				Branch:       "feature",
+				Comment:      "Retry",
+				Params:       map[string]string{"env.B": "3", "env.C": "4"},
+				SameRevision: true,
+				Tags:         []string{"rebuild"},
+			},
+			wantBranch:    "feature",
+			wantComment:   "Retry",
+			wantParams:    map[string]string{"env.A": "1", "env.B": "3", "env.C": "4"},
+			wantRevisions: true,
+			wantTags:      []string{"rebuild"},
+		},
+	}
+
+	for _, test := range tests {
+		payload := rebuildPayload(originalBuild, test.options)
+
+		if payload.BuildType.ID != "P1" || payload.BranchName != test.wantBranch || payload.Comment.Text != test.wantComment || payload.Personal != "false" {
+			t.Errorf("%s: got payload %+v", test.name, payload)
+		}
+
+		params := map[string]string{}
+		for _, property := range payload.Properties.Property {
+			if _, ok := params[property.Name]; ok {
+				t.Errorf("%s: param %s is sent twice", test.name, property.Name)
+			}
+			params[property.Name] = property.Value
+		}
+		if !reflect.DeepEqual(params, test.wantParams) {
+			t.Errorf("%s: got params %v, want %v", test.name, params, test.wantParams)
+		}
+
+		wantSnapshot := &TCBuildSnapshotDependencies{Builds: []TCBuildDetails{{ID: 8, BuildTypeID: "Lib"}}}
+		wantArtifact := &TCBuildSnapshotDependencies{Builds: []TCBuildDetails{{ID: 9, BuildTypeID: "Assets"}}}
+		if !reflect.DeepEqual(payload.SnapshotDependencies, wantSnapshot) || !reflect.DeepEqual(payload.ArtifactDependencies, wantArtifact) {
+			t.Errorf("%s: got dependencies %+v and %+v, want references to the original ones", test.name, payload.SnapshotDependencies, payload.ArtifactDependencies)
+		}
+
+		if (payload.Revisions != nil) != test.wantRevisions || (test.wantRevisions && payload.Revisions != originalBuild.Revisions) {
+			t.Errorf("%s: got revisions %+v", test.name, payload.Revisions)
+		}
+
+		tags := []string{}
+		if payload.Tags != nil {
+			for _, tag := range payload.Tags.Tag {
+				tags = append(tags, tag.Name)
+			}
+		}
+		sort.Strings(tags)
+		if len(tags) != len(test.wantTags) || (len(tags) > 0 && !reflect.DeepEqual(tags, test.wantTags)) {
+			t.Errorf("%s: got tags %v, want %v", test.name, tags, test.wantTags)
+		}
+	}
+}
+
+func TestRebuildBuild(t *testing.T) {
+	var payload TCBuildPayload
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/app/rest/builds/id:10":
+			json.NewEncoder(w).Encode(originalBuild)
+		case r.Method == "POST" && r.URL.Path == "/app/rest/buildQueue":
+			json.NewDecoder(r.Body).Decode(&payload)
+			json.NewEncoder(w).Encode(TCBuildDetails{ID: 11})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	id, err := client.RebuildBuild(context.Background(), 10, TCRebuildOptions{SameRevision: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 11 {
+		t.Errorf("got build %d, want 11", id)
+	}
+	if payload.BuildType.ID != "P1" || payload.Revisions == nil || payload.Revisions.Revision[0].Version != "abc" {
+		t.Errorf("got payload %+v, want the inputs of build 10", payload)
+	}
+
+	if id, err := client.RebuildBuild(context.Background(), 12, TCRebuildOptions{}); err == nil || id != -1 {
+		t.Errorf("got build %d and error %v, want an error for an unknown build", id, err)
+	}
+}
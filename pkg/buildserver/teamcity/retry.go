@@ -0,0 +1,96 @@
+package teamcity
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// RetryPolicy decides whether a failed build is re-queued
+type RetryPolicy struct {
+	MaxRetries   int            // Maximum number of times a failed build is re-queued
+	RetryOn      *regexp.Regexp // Retry when the status text or a build problem matches. When nil, retry on infrastructure failures
+	PollInterval time.Duration  // Interval at which running builds are polled
+}
+
+// ShouldRetry reports whether a finished build failed in
+// a way the policy considers worth retrying
+func (p RetryPolicy) ShouldRetry(build TCBuildDetails, problems []TCProblemOccurrence) bool {
+	if build.Status == "SUCCESS" {
+		return false
+	}
+
+	if p.RetryOn == nil {
+		if infrastructureText(build.StatusText) {
+			return true
+		}
+		for _, problem := range problems {
+			if problem.Infrastructure() {
+				return true
+			}
+		}
+		return false
+	}
+
+	if p.RetryOn.MatchString(build.StatusText) {
+		return true
+	}
+	for _, problem := range problems {
+		if p.RetryOn.MatchString(problem.Details) || p.RetryOn.MatchString(problem.Identity) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+StartBuildWithRetry adds a build to the build queue, waits for it to finish
+and re-queues it with identical inputs as long as the policy asks for it
+
+Retries are queued with the same options as the first build, pinned to the
+revisions the first build ran on. They are tagged with "retry" in addition
+to options.Tags and refer to the build they retry in their comment.
+
+It returns every attempt in the order they were made, the last one being
+the final outcome
+*/
+func (t *TCClient) StartBuildWithRetry(ctx context.Context, options StartBuildOptions, policy RetryPolicy) ([]TCBuildDetails, error) {
+	id, err := t.StartBuildWithOptions(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := []TCBuildDetails{}
+	retryOptions := options
+	retryOptions.Tags = append(append([]string{}, options.Tags...), "retry")
+	for retry := 1; ; retry++ {
+		build, err := t.WaitForBuild(ctx, id, policy.PollInterval)
+		if err != nil {
+			return attempts, err
+		}
+		attempts = append(attempts, build)
+
+		if build.Status == "SUCCESS" || retry > policy.MaxRetries {
+			return attempts, nil
+		}
+
+		problems, err := t.ListProblemOccurrences(ctx, id)
+		if err != nil {
+			return attempts, err
+		}
+		if !policy.ShouldRetry(build, problems) {
+			return attempts, nil
+		}
+
+		if retry == 1 && build.Revisions != nil && len(build.Revisions.Revision) > 0 {
+			retryOptions.Revisions = build.Revisions.Revision
+		}
+		retryOptions.Comment = fmt.Sprintf("Retry %d of %d for build %d: %s", retry, policy.MaxRetries, build.ID, build.StatusText)
+
+		id, err = t.StartBuildWithOptions(ctx, retryOptions)
+		if err != nil {
+			return attempts, err
+		}
+	}
+}
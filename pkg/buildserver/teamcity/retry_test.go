@@ -0,0 +1,142 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestShouldRetry(t *testing.T) {
+	infrastructure := []TCProblemOccurrence{{Type: ProblemExitCode, Details: "Agent was disconnected"}}
+	code := []TCProblemOccurrence{{Type: ProblemCompilationError, Identity: "compile", Details: "syntax error"}}
+
+	tests := []struct {
+		name     string
+		retryOn  string
+		build    TCBuildDetails
+		problems []TCProblemOccurrence
+		want     bool
+	}{
+		{"success", "", TCBuildDetails{Status: "SUCCESS", StatusText: "Agent was disconnected"}, infrastructure, false},
+		{"infrastructure problem", "", TCBuildDetails{Status: "FAILURE"}, infrastructure, true},
+		{"code problem", "", TCBuildDetails{Status: "FAILURE"}, code, false},
+		{"infrastructure status text", "", TCBuildDetails{Status: "FAILURE", StatusText: "java.lang.OutOfMemoryError"}, nil, true},
+		{"code status text", "", TCBuildDetails{Status: "FAILURE", StatusText: "Tests failed: 3"}, code, false},
+		{"status text matches", "Tests failed", TCBuildDetails{Status: "FAILURE", StatusText: "Tests failed: 3"}, nil, true},
+		{"problem identity matches", "^compile$", TCBuildDetails{Status: "FAILURE"}, code, true},
+		{"no match", "timeout", TCBuildDetails{Status: "FAILURE", StatusText: "Agent was disconnected"}, infrastructure, false},
+	}
+
+	for _, test := range tests {
+		policy := RetryPolicy{}
+		if test.retryOn != "" {
+			policy.RetryOn = regexp.MustCompile(test.retryOn)
+		}
+		if got := policy.ShouldRetry(test.build, test.problems); got != test.want {
+			t.Errorf("%s: ShouldRetry() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestStartBuildWithRetry(t *testing.T) {
+	revisions := []TCRevision{
+		{Version: "abc", VcsRootInstance: &TCVcsRootInstance{ID: "1"}},
+		{Version: "def", VcsRootInstance: &TCVcsRootInstance{ID: "2"}},
+	}
+
+	var (
+		mu       sync.Mutex
+		payloads []TCBuildPayload
+	)
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var id int
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/app/rest/buildQueue":
+			var payload TCBuildPayload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			payloads = append(payloads, payload)
+			json.NewEncoder(w).Encode(TCBuildDetails{ID: len(payloads)})
+		case r.URL.Path == "/app/rest/problemOccurrences":
+			json.NewEncoder(w).Encode(TCProblemOccurrences{})
+		case r.Method == "GET":
+			if _, err := fmt.Sscanf(r.URL.Path, "/app/rest/builds/id:%d", &id); err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			// The first two attempts lose their agent, the third succeeds
+			build := TCBuildDetails{ID: id, State: "finished", Status: "FAILURE", StatusText: "Agent was disconnected"}
+			if id == 1 {
+				build.Revisions = &TCRevisions{Revision: revisions}
+			}
+			if id == 3 {
+				build.Status, build.StatusText = "SUCCESS", "Success"
+			}
+			json.NewEncoder(w).Encode(build)
+		default:
+			http.Error(w, "unexpected request", http.StatusNotFound)
+		}
+	})
+	defer server.Close()
+
+	options := StartBuildOptions{
+		BuildTypeID:  "P1",
+		Branch:       "main",
+		Personal:     true,
+		Revision:     "abc",
+		CleanSources: true,
+		AgentID:      7,
+		Params:       map[string]string{"env.A": "1"},
+		Tags:         []string{"nightly"},
+	}
+	attempts, err := client.StartBuildWithRetry(context.Background(), options, RetryPolicy{MaxRetries: 3, PollInterval: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 3 || attempts[2].Status != "SUCCESS" {
+		t.Fatalf("got attempts %+v, want 3 ending in success", attempts)
+	}
+
+	for i, payload := range payloads[1:] {
+		if payload.BuildType.ID != "P1" || payload.BranchName != "main" || payload.Personal != "true" {
+			t.Errorf("retry %d: got payload %+v, want the inputs of the first build", i+1, payload)
+		}
+		if payload.TriggeringOptions == nil || !payload.TriggeringOptions.CleanSources || payload.Agent == nil || payload.Agent.ID != 7 {
+			t.Errorf("retry %d: got triggering options %+v and agent %+v", i+1, payload.TriggeringOptions, payload.Agent)
+		}
+		if len(payload.Properties.Property) != 1 || payload.Properties.Property[0] != (TCBuildProperty{"env.A", "1"}) {
+			t.Errorf("retry %d: got params %+v", i+1, payload.Properties.Property)
+		}
+		if payload.Revisions == nil || !reflect.DeepEqual(payload.Revisions.Revision, revisions) || payload.LastChanges != nil {
+			t.Errorf("retry %d: got revisions %+v and changes %+v, want the revisions of the first build", i+1, payload.Revisions, payload.LastChanges)
+		}
+
+		tags := []string{}
+		for _, tag := range payload.Tags.Tag {
+			tags = append(tags, tag.Name)
+		}
+		sort.Strings(tags)
+		if !reflect.DeepEqual(tags, []string{"nightly", "retry"}) {
+			t.Errorf("retry %d: got tags %v", i+1, tags)
+		}
+
+		want := fmt.Sprintf("Retry %d of 3 for build %d: Agent was disconnected", i+1, i+1)
+		if payload.Comment.Text != want {
+			t.Errorf("retry %d: got comment %q, want %q", i+1, payload.Comment.Text, want)
+		}
+	}
+	if len(options.Tags) != 1 {
+		t.Errorf("the tags of the options were modified: %v", options.Tags)
+	}
+}
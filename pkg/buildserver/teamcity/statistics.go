@@ -0,0 +1,89 @@
+package teamcity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// maxConcurrentStatistics limits the number of statistics requests
+// that are made to teamcity in parallel
+const maxConcurrentStatistics = 8
+
+// GetBuildStatistics returns the statistic values of a build keyed by
+// statistic name. It includes values reported by teamcity such as
+// BuildDuration and custom values reported by the build with the
+// buildStatisticValue service message
+func (t *TCClient) GetBuildStatistics(ctx context.Context, id int) (map[string]float64, error) {
+	var properties TCBuildProperties
+	if err := t.doRequest(ctx, "GET", fmt.Sprintf("/app/rest/builds/id:%d/statistics", id), nil, &properties); err != nil {
+		return nil, err
+	}
+
+	statistics := make(map[string]float64, len(properties.Property))
+	for _, property := range properties.Property {
+		value, err := strconv.ParseFloat(property.Value, 64)
+		if err != nil {
+			// Statistics are numeric, anything else is skipped
+			continue
+		}
+		statistics[property.Name] = value
+	}
+	return statistics, nil
+}
+
+// GetStatisticSeries collects the value of one statistic across the most
+// recent builds of a pipeline, oldest first. Builds that did not report
+// the statistic are skipped. The number of builds must be provided, the
+// statistics of the builds are fetched concurrently
+func (t *TCClient) GetStatisticSeries(ctx context.Context, params TCStatisticQueryParams) ([]TCStatisticPoint, error) {
+	if params.Builds == 0 {
+		return nil, errors.New("the number of builds to collect the statistic from is required")
+	}
+
+	builds, err := ListBuilds(ctx, t, TCQueryParams{
+		BuildTypeID: params.BuildTypeID,
+		Branch:      params.Branch,
+		Fields:      "id,number,finishDate",
+	}, params.Builds)
+	if err != nil {
+		return nil, err
+	}
+
+	statistics := make([]map[string]float64, len(builds))
+	errs := make([]error, len(builds))
+	sem := make(chan struct{}, maxConcurrentStatistics)
+	var wg sync.WaitGroup
+	for i, build := range builds {
+		wg.Add(1)
+		go func(i, id int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			statistics[i], errs[i] = t.GetBuildStatistics(ctx, id)
+		}(i, build.ID)
+	}
+	wg.Wait()
+
+	points := []TCStatisticPoint{}
+	for i := len(builds) - 1; i >= 0; i-- {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+
+		value, ok := statistics[i][params.Metric]
+		if !ok {
+			continue
+		}
+		points = append(points, TCStatisticPoint{
+			BuildID:    builds[i].ID,
+			Number:     builds[i].Number,
+			FinishDate: builds[i].FinishDate,
+			Value:      value,
+		})
+	}
+	return points, nil
+}
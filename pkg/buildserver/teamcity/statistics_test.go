@@ -0,0 +1,108 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGetBuildStatistics(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/app/rest/builds/id:1/statistics" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(TCBuildProperties{Property: []TCBuildProperty{
+			{Name: "BuildDuration", Value: "1200"},
+			{Name: "coverage", Value: "81.5"},
+			{Name: "label", Value: "not a number"},
+		}})
+	})
+	defer server.Close()
+
+	statistics, err := client.GetBuildStatistics(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"BuildDuration": 1200, "coverage": 81.5}
+	if !reflect.DeepEqual(statistics, want) {
+		t.Errorf("got statistics %v, want %v", statistics, want)
+	}
+
+	if _, err := client.GetBuildStatistics(context.Background(), 2); err == nil {
+		t.Error("expected an error for an unknown build")
+	}
+}
+
+func TestGetStatisticSeries(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		locator  string
+		requests = map[string]bool{}
+	)
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var id int
+		switch {
+		case r.URL.Path == "/app/rest/builds":
+			locator = r.URL.Query().Get("locator")
+			// Builds are listed newest first
+			json.NewEncoder(w).Encode(TCBuildSnapshotDependencies{Builds: []TCBuildDetails{
+				{ID: 3, Number: "3", FinishDate: "20200103T000000+0000"},
+				{ID: 2, Number: "2", FinishDate: "20200102T000000+0000"},
+				{ID: 1, Number: "1", FinishDate: "20200101T000000+0000"},
+			}})
+		case scanStatisticsPath(r.URL.Path, &id):
+			requests[r.URL.Path] = true
+			properties := TCBuildProperties{}
+			if id != 2 {
+				properties.Property = []TCBuildProperty{{Name: "coverage", Value: fmt.Sprint(id * 10)}}
+			}
+			json.NewEncoder(w).Encode(properties)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	points, err := client.GetStatisticSeries(context.Background(), TCStatisticQueryParams{
+		BuildTypeID: "P1",
+		Branch:      "main",
+		Metric:      "coverage",
+		Builds:      3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TCStatisticPoint{
+		{BuildID: 1, Number: "1", FinishDate: "20200101T000000+0000", Value: 10},
+		{BuildID: 3, Number: "3", FinishDate: "20200103T000000+0000", Value: 30},
+	}
+	if !reflect.DeepEqual(points, want) {
+		t.Errorf("got points %+v, want %+v", points, want)
+	}
+	if len(requests) != 3 {
+		t.Errorf("got statistics requests %v, want one per build", requests)
+	}
+	wantLocator := "buildType:(id:" + locatorValue("P1") + "),branch:(name:" + locatorValue("main") + "),count:3"
+	if locator != wantLocator {
+		t.Errorf("got locator %q, want %q", locator, wantLocator)
+	}
+
+	if _, err := client.GetStatisticSeries(context.Background(), TCStatisticQueryParams{BuildTypeID: "P1", Metric: "coverage"}); err == nil {
+		t.Error("expected an error without a number of builds")
+	}
+}
+
+// scanStatisticsPath reads the build id of a statistics request path
+func scanStatisticsPath(path string, id *int) bool {
+	n, _ := fmt.Sscanf(path, "/app/rest/builds/id:%d/statistics", id)
+	return n == 1 && strings.HasSuffix(path, "/statistics")
+}
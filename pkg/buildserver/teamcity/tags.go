@@ -0,0 +1,73 @@
+package teamcity
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetTags returns the tags of a build
+func (t *TCClient) GetTags(ctx context.Context, id int) ([]string, error) {
+	var tags TCTags
+	if err := t.doRequest(ctx, "GET", fmt.Sprintf("/app/rest/builds/id:%d/tags", id), nil, &tags); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(tags.Tag))
+	for _, tag := range tags.Tag {
+		names = append(names, tag.Name)
+	}
+	return names, nil
+}
+
+// AddTags adds tags to a build keeping its existing tags
+func (t *TCClient) AddTags(ctx context.Context, id int, tags []string) error {
+	return t.doRequest(ctx, "POST", fmt.Sprintf("/app/rest/builds/id:%d/tags", id), newTCTags(tags), nil)
+}
+
+// ReplaceTags replaces all tags of a build with the provided tags
+func (t *TCClient) ReplaceTags(ctx context.Context, id int, tags []string) error {
+	return t.doRequest(ctx, "PUT", fmt.Sprintf("/app/rest/builds/id:%d/tags", id), newTCTags(tags), nil)
+}
+
+// RemoveTags removes the provided tags from a build keeping the rest of
+// its tags. The tags are read and then replaced, which is not atomic: tags
+// added by someone else in between are dropped
+func (t *TCClient) RemoveTags(ctx context.Context, id int, tags []string) error {
+	existing, err := t.GetTags(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		remove[tag] = true
+	}
+
+	remaining := []string{}
+	for _, tag := range existing {
+		if !remove[tag] {
+			remaining = append(remaining, tag)
+		}
+	}
+
+	return t.ReplaceTags(ctx, id, remaining)
+}
+
+// PinBuild pins a build so that it is not removed
+// by the teamcity clean-up
+func (t *TCClient) PinBuild(ctx context.Context, id int, comment string) error {
+	return t.doRequest(ctx, "PUT", fmt.Sprintf("/app/rest/builds/id:%d/pin", id), comment, nil)
+}
+
+// UnpinBuild unpins a previously pinned build
+func (t *TCClient) UnpinBuild(ctx context.Context, id int, comment string) error {
+	return t.doRequest(ctx, "DELETE", fmt.Sprintf("/app/rest/builds/id:%d/pin", id), comment, nil)
+}
+
+func newTCTags(tags []string) TCTags {
+	tcTags := TCTags{Tag: []TCTag{}}
+	for _, tag := range tags {
+		tcTags.Tag = append(tcTags.Tag, TCTag{Name: tag})
+	}
+	return tcTags
+}
@@ -0,0 +1,114 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	var (
+		tags     = []string{"nightly", "release", "keep"}
+		requests []string
+		pins     []string
+	)
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch r.URL.Path {
+		case "/app/rest/builds/id:1/tags":
+			if r.Method == "GET" {
+				json.NewEncoder(w).Encode(newTCTags(tags))
+				return
+			}
+			var body TCTags
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if r.Method == "PUT" {
+				tags = nil
+			}
+			for _, tag := range body.Tag {
+				tags = append(tags, tag.Name)
+			}
+		case "/app/rest/builds/id:1/pin":
+			body, _ := ioutil.ReadAll(r.Body)
+			pins = append(pins, r.Method+" "+r.Header.Get("Content-Type")+" "+string(body))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+	ctx := context.Background()
+
+	got, err := client.GetTags(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, tags) {
+		t.Errorf("got tags %v, want %v", got, tags)
+	}
+
+	if err := client.AddTags(ctx, 1, []string{"a,b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.RemoveTags(ctx, 1, []string{"release", "unknown"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"nightly", "keep", "a,b"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("got tags %v, want %v", tags, want)
+	}
+
+	if err := client.ReplaceTags(ctx, 1, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("got tags %v, want none", tags)
+	}
+
+	if err := client.PinBuild(ctx, 1, "release candidate"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.UnpinBuild(ctx, 1, "released"); err != nil {
+		t.Fatal(err)
+	}
+	wantPins := []string{"PUT text/plain release candidate", "DELETE text/plain released"}
+	if !reflect.DeepEqual(pins, wantPins) {
+		t.Errorf("got pin requests %q, want %q", pins, wantPins)
+	}
+
+	wantRequests := "GET,POST,GET,PUT,PUT,PUT,DELETE"
+	methods := []string{}
+	for _, request := range requests {
+		methods = append(methods, strings.Fields(request)[0])
+	}
+	if got := strings.Join(methods, ","); got != wantRequests {
+		t.Errorf("got requests %v, want methods %s", requests, wantRequests)
+	}
+
+	if err := client.AddTags(ctx, 2, []string{"x"}); err == nil {
+		t.Error("expected an error for an unknown build")
+	}
+}
+
+func TestGetAllBuildsTags(t *testing.T) {
+	var locator string
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		locator = r.URL.Query().Get("locator")
+		json.NewEncoder(w).Encode(TCBuildSnapshotDependencies{})
+	})
+	defer server.Close()
+
+	if _, err := client.GetAllBuilds(TCQueryParams{Tags: []string{"a,b:(c)", "d"}, Pinned: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := "tag:" + locatorValue("a,b:(c)") + ",tag:" + locatorValue("d") + ",pinned:true"
+	if !strings.HasPrefix(locator, want) {
+		t.Errorf("got locator %q, want it to start with %q", locator, want)
+	}
+}
@@ -2,6 +2,7 @@ package teamcity
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -9,10 +10,20 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
 
+// TimeFormat is the layout of timestamps such as queuedDate, startDate
+// and finishDate returned by teamcity
+const TimeFormat = "20060102T150405-0700"
+
+// ParseTime parses a timestamp returned by teamcity
+func ParseTime(value string) (time.Time, error) {
+	return time.Parse(TimeFormat, value)
+}
+
 // TCClient is client object to talk to teamcity
 type TCClient struct {
 	client    *http.Client
@@ -84,6 +95,22 @@ func (t *TCClient) GetBuild(id int, buildDetails interface{}) (err error) {
 	return
 }
 
+// GetBuildDetails returns build details for the provided id. Unlike
+// GetBuild, it honours ctx and returns an error for non 2xx responses
+func (t *TCClient) GetBuildDetails(ctx context.Context, id int) (TCBuildDetails, error) {
+	var buildDetails TCBuildDetails
+	err := t.doRequest(ctx, "GET", fmt.Sprintf("/app/rest/builds/id:%d", id), nil, &buildDetails)
+	return buildDetails, err
+}
+
+// queueBuild adds the build described by payload to the build queue
+// and returns the details of the queued build
+func (t *TCClient) queueBuild(ctx context.Context, payload TCBuildPayload) (TCBuildDetails, error) {
+	var buildDetails TCBuildDetails
+	err := t.doRequest(ctx, "POST", "/app/rest/buildQueue", payload, &buildDetails)
+	return buildDetails, err
+}
+
 /*
 StartBuild adds a build to the build queue
 
@@ -99,24 +126,69 @@ func (t *TCClient) StartBuild(
 	params map[string]string,
 	snapshotDependencies map[string]int,
 	artifactDependencies map[string]int) (int, error) {
-	var buildDetails TCBuildDetails
+	return t.StartBuildWithOptions(context.Background(), StartBuildOptions{
+		BuildTypeID:          buildTypeID,
+		Branch:               branch,
+		Comment:              comment,
+		Params:               params,
+		SnapshotDependencies: snapshotDependencies,
+		ArtifactDependencies: artifactDependencies,
+	})
+}
+
+// StartBuildWithOptions adds a build described by options to the build
+// queue and returns the id of the queued build
+func (t *TCClient) StartBuildWithOptions(ctx context.Context, options StartBuildOptions) (int, error) {
+	buildDetails, err := t.queueBuild(ctx, options.payload())
+	if err != nil {
+		log.Println(err.Error())
+		return -1, err
+	}
+
+	return buildDetails.ID, nil
+}
 
+// payload creates the teamcity trigger payload for the options
+func (o StartBuildOptions) payload() TCBuildPayload {
 	payload := TCBuildPayload{
 		BuildType: TCBuildType{
-			ID: buildTypeID,
+			ID: o.BuildTypeID,
 		},
 		Comment: TCBuildComment{
-			Text: comment,
+			Text: o.Comment,
 		},
 		Properties: TCBuildProperties{
 			Property: []TCBuildProperty{},
 		},
-		Personal:   "False",
-		BranchName: branch,
+		Personal:   fmt.Sprintf("%t", o.Personal),
+		BranchName: o.Branch,
+	}
+
+	if o.CleanSources || o.RebuildAllDependencies || o.QueueAtTop {
+		payload.TriggeringOptions = &TCTriggeringOptions{
+			CleanSources:           o.CleanSources,
+			RebuildAllDependencies: o.RebuildAllDependencies,
+			QueueAtTop:             o.QueueAtTop,
+		}
+	}
+
+	// Pin the build to an agent or an agent pool
+	switch {
+	case o.AgentID > 0:
+		payload.Agent = &TCAgent{ID: o.AgentID}
+	case o.AgentName != "":
+		payload.Agent = &TCAgent{Locator: fmt.Sprintf("name:%s", locatorValue(o.AgentName))}
+	case o.AgentPoolID > 0:
+		payload.Agent = &TCAgent{Pool: &TCAgentPool{ID: o.AgentPoolID}}
+	}
+
+	if len(o.Tags) > 0 {
+		tags := newTCTags(o.Tags)
+		payload.Tags = &tags
 	}
 
 	// Add params to properties
-	for k, v := range params {
+	for k, v := range o.Params {
 		payload.Properties.Property = append(payload.Properties.Property, TCBuildProperty{k, v})
 	}
 
@@ -128,12 +200,12 @@ func (t *TCClient) StartBuild(
 	}
 
 	// Add snapshot dependencies to request
-	for k, v := range snapshotDependencies {
+	for k, v := range o.SnapshotDependencies {
 		snapDeps.Builds = append(snapDeps.Builds, TCBuildDetails{ID: v, BuildTypeID: k})
 	}
 
 	// Add artifact dependencies to request
-	for k, v := range artifactDependencies {
+	for k, v := range o.ArtifactDependencies {
 		artfDeps.Builds = append(artfDeps.Builds, TCBuildDetails{ID: v, BuildTypeID: k})
 	}
 
@@ -145,47 +217,31 @@ func (t *TCClient) StartBuild(
 		payload.ArtifactDependencies = &artfDeps
 	}
 
-	requestPayload, err := json.Marshal(payload)
-	if err != nil {
-		log.Println(err.Error())
-		return -1, err
-	}
-
-	log.Println(string(requestPayload))
-
-	req, err := http.NewRequest(
-		"POST",
-		fmt.Sprintf("%s/app/rest/buildQueue", t.serverURL),
-		bytes.NewBuffer(requestPayload))
-	if err != nil {
-		return -1, err
-	}
-	t.setAuthorizationHeader(req.Header)
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Content-Type", "application/json")
-
-	resp, err := t.client.Do(req)
-	if err != nil {
-		log.Println(err.Error())
-		return -1, err
-	}
-
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Println(err.Error())
-		return -1, err
-	}
-
-	log.Printf(string(body))
-	err = json.Unmarshal(body, &buildDetails)
-	if err != nil {
-		log.Println(err.Error())
-		return -1, err
-	}
-
-	log.Println(buildDetails)
-	return buildDetails.ID, nil
+	// Pin the build to a specific change or revision
+	switch {
+	case len(o.Revisions) > 0:
+		payload.Revisions = &TCRevisions{Revision: o.Revisions}
+	case o.ChangeID > 0:
+		payload.LastChanges = &TCChanges{
+			Change: []TCChange{{ID: o.ChangeID}},
+		}
+	case o.Revision != "" && o.VcsRootInstanceID != "":
+		payload.Revisions = &TCRevisions{
+			Revision: []TCRevision{{
+				Version:         o.Revision,
+				VcsRootInstance: &TCVcsRootInstance{ID: o.VcsRootInstanceID},
+			}},
+		}
+	case o.Revision != "":
+		// Let teamcity resolve the revision among the changes of the pipeline
+		payload.LastChanges = &TCChanges{
+			Change: []TCChange{{
+				Locator: fmt.Sprintf("version:%s,buildType:(id:%s)", locatorValue(o.Revision), locatorValue(o.BuildTypeID)),
+			}},
+		}
+	}
+
+	return payload
 }
 
 // CancelQueuedBuild cancels a build that is currently
@@ -325,6 +381,56 @@ func (t *TCClient) setAuthorizationHeader(headers http.Header) {
 	headers.Add("Authorization", fmt.Sprintf("Bearer %s", t.token))
 }
 
+// doRequest makes an authenticated request to the teamcity REST API.
+// path is relative to the server URL and payload, when not nil, is sent
+// as plain text if it is a string and as JSON otherwise. The response
+// body is decoded into out when out is not nil.
+// Responses with a non 2xx status code are returned as error
+func (t *TCClient) doRequest(ctx context.Context, method, path string, payload, out interface{}) error {
+	reqBody := &bytes.Buffer{}
+	contentType := "application/json"
+	switch p := payload.(type) {
+	case nil:
+	case string:
+		reqBody = bytes.NewBufferString(p)
+		contentType = "text/plain"
+	default:
+		requestPayload, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewBuffer(requestPayload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s%s", t.serverURL, path), reqBody)
+	if err != nil {
+		return err
+	}
+	t.setAuthorizationHeader(req.Header)
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Content-Type", contentType)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s failed with status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
 // GetAllBuilds returns the list of builds as per the query params
 // provided by user
 func (t *TCClient) GetAllBuilds(params TCQueryParams) (builds TCBuildSnapshotDependencies, err error) {
@@ -354,6 +460,22 @@ func (t *TCClient) GetAllBuilds(params TCQueryParams) (builds TCBuildSnapshotDep
 		requestURL = fmt.Sprintf("%s%s", requestURL, fmt.Sprintf("lookupLimit:%d,", params.LookupLimit))
 	}
 
+	for _, tag := range params.Tags {
+		requestURL = fmt.Sprintf("%s%s", requestURL, fmt.Sprintf("tag:%s,", url.QueryEscape(locatorValue(tag))))
+	}
+
+	if params.SinceBuild > 0 {
+		requestURL = fmt.Sprintf("%s%s", requestURL, fmt.Sprintf("sinceBuild:(id:%d),", params.SinceBuild))
+	}
+
+	if !params.SinceDate.IsZero() {
+		requestURL = fmt.Sprintf("%s%s", requestURL, fmt.Sprintf("sinceDate:%s,", url.QueryEscape(params.SinceDate.Format(TimeFormat))))
+	}
+
+	if params.Pinned {
+		requestURL = fmt.Sprintf("%s%s", requestURL, fmt.Sprintf("pinned:%t,", params.Pinned))
+	}
+
 	if params.Running {
 		requestURL = fmt.Sprintf("%s%s", requestURL, fmt.Sprintf("running:%t,", params.Running))
 	}
@@ -362,6 +484,10 @@ func (t *TCClient) GetAllBuilds(params TCQueryParams) (builds TCBuildSnapshotDep
 		requestURL = fmt.Sprintf("%s%s", requestURL, fmt.Sprintf("cancelled:%t,", params.Cancelled))
 	}
 
+	if params.Fields != "" {
+		requestURL = fmt.Sprintf("%s&fields=count,build(%s)", requestURL, params.Fields)
+	}
+
 	req, err := http.NewRequest("GET", requestURL, nil)
 	if err != nil {
 		return
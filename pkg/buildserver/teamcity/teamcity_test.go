@@ -0,0 +1,155 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestPayloadRevision(t *testing.T) {
+	revisions := []TCRevision{{Version: "def", VcsRootInstance: &TCVcsRootInstance{ID: "2"}}}
+
+	tests := []struct {
+		name          string
+		options       StartBuildOptions
+		wantRevisions *TCRevisions
+		wantChanges   *TCChanges
+	}{
+		{
+			name:    "no revision",
+			options: StartBuildOptions{BuildTypeID: "P1", Branch: "main"},
+		},
+		{
+			name:        "change",
+			options:     StartBuildOptions{BuildTypeID: "P1", ChangeID: 42, Revision: "abc"},
+			wantChanges: &TCChanges{Change: []TCChange{{ID: 42}}},
+		},
+		{
+			name:    "revision of a vcs root instance",
+			options: StartBuildOptions{BuildTypeID: "P1", Branch: "main", Revision: "abc", VcsRootInstanceID: "1"},
+			wantRevisions: &TCRevisions{Revision: []TCRevision{{
+				Version:         "abc",
+				VcsRootInstance: &TCVcsRootInstance{ID: "1"},
+			}}},
+		},
+		{
+			name:    "revision resolved by teamcity",
+			options: StartBuildOptions{BuildTypeID: "Project_Build", Revision: "abc"},
+			wantChanges: &TCChanges{Change: []TCChange{{
+				Locator: "version:" + locatorValue("abc") + ",buildType:(id:" + locatorValue("Project_Build") + ")",
+			}}},
+		},
+		{
+			name:          "revisions take precedence",
+			options:       StartBuildOptions{BuildTypeID: "P1", Revisions: revisions, ChangeID: 42, Revision: "abc"},
+			wantRevisions: &TCRevisions{Revision: revisions},
+		},
+	}
+
+	for _, test := range tests {
+		payload := test.options.payload()
+		if !reflect.DeepEqual(payload.Revisions, test.wantRevisions) {
+			t.Errorf("%s: got revisions %+v, want %+v", test.name, payload.Revisions, test.wantRevisions)
+		}
+		if !reflect.DeepEqual(payload.LastChanges, test.wantChanges) {
+			t.Errorf("%s: got changes %+v, want %+v", test.name, payload.LastChanges, test.wantChanges)
+		}
+	}
+}
+
+func TestStartBuildWithOptions(t *testing.T) {
+	var body map[string]interface{}
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/app/rest/buildQueue" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(TCBuildDetails{ID: 5})
+	})
+	defer server.Close()
+
+	id, err := client.StartBuildWithOptions(context.Background(), StartBuildOptions{
+		BuildTypeID:       "P1",
+		Branch:            "main",
+		Revision:          "abc",
+		VcsRootInstanceID: "1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 5 {
+		t.Errorf("got build %d, want 5", id)
+	}
+
+	want := map[string]interface{}{
+		"revision": []interface{}{map[string]interface{}{
+			"version":           "abc",
+			"vcs-root-instance": map[string]interface{}{"id": "1"},
+		}},
+	}
+	if !reflect.DeepEqual(body["revisions"], want) || body["branchName"] != "main" {
+		t.Errorf("got payload %v, want the revision without a vcs branch", body)
+	}
+}
+
+func TestPayloadTriggeringOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     StartBuildOptions
+		wantOptions *TCTriggeringOptions
+		wantAgent   *TCAgent
+		personal    string
+	}{
+		{
+			name:     "defaults",
+			options:  StartBuildOptions{BuildTypeID: "P1"},
+			personal: "false",
+		},
+		{
+			name:        "clean personal build at the top of the queue",
+			options:     StartBuildOptions{BuildTypeID: "P1", Personal: true, CleanSources: true, QueueAtTop: true},
+			wantOptions: &TCTriggeringOptions{CleanSources: true, QueueAtTop: true},
+			personal:    "true",
+		},
+		{
+			name:        "rebuild dependencies",
+			options:     StartBuildOptions{BuildTypeID: "P1", RebuildAllDependencies: true},
+			wantOptions: &TCTriggeringOptions{RebuildAllDependencies: true},
+			personal:    "false",
+		},
+		{
+			name:      "agent id takes precedence",
+			options:   StartBuildOptions{BuildTypeID: "P1", AgentID: 7, AgentName: "agent-7", AgentPoolID: 3},
+			wantAgent: &TCAgent{ID: 7},
+			personal:  "false",
+		},
+		{
+			name:      "agent name",
+			options:   StartBuildOptions{BuildTypeID: "P1", AgentName: "linux,large:(1)", AgentPoolID: 3},
+			wantAgent: &TCAgent{Locator: "name:" + locatorValue("linux,large:(1)")},
+			personal:  "false",
+		},
+		{
+			name:      "agent pool",
+			options:   StartBuildOptions{BuildTypeID: "P1", AgentPoolID: 3},
+			wantAgent: &TCAgent{Pool: &TCAgentPool{ID: 3}},
+			personal:  "false",
+		},
+	}
+
+	for _, test := range tests {
+		payload := test.options.payload()
+		if !reflect.DeepEqual(payload.TriggeringOptions, test.wantOptions) {
+			t.Errorf("%s: got triggering options %+v, want %+v", test.name, payload.TriggeringOptions, test.wantOptions)
+		}
+		if !reflect.DeepEqual(payload.Agent, test.wantAgent) {
+			t.Errorf("%s: got agent %+v, want %+v", test.name, payload.Agent, test.wantAgent)
+		}
+		if payload.Personal != test.personal {
+			t.Errorf("%s: got personal %q, want %q", test.name, payload.Personal, test.personal)
+		}
+	}
+}
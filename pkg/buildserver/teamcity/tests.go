@@ -0,0 +1,84 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const testOccurrenceFields = "count,nextHref,testOccurrence(id,name,status,duration,ignored,muted,currentlyMuted,newFailure,details," +
+	"test(id,name),build(id,buildTypeId,number,branchName,status,webUrl))"
+
+// ListTestOccurrences returns the results of the tests run
+// in a build as per the query params
+func (t *TCClient) ListTestOccurrences(ctx context.Context, buildID int, params TCTestQueryParams) ([]TCTestOccurrence, error) {
+	locator := []string{fmt.Sprintf("build:(id:%d)", buildID)}
+
+	if params.Status != "" {
+		locator = append(locator, fmt.Sprintf("status:%s", params.Status))
+	}
+
+	if params.NewFailure {
+		locator = append(locator, "newFailure:true")
+	}
+
+	if params.Muted {
+		locator = append(locator, "muted:true")
+	}
+
+	if params.Ignored {
+		locator = append(locator, "ignored:true")
+	}
+
+	return t.listTestOccurrences(ctx, locator, params.Count)
+}
+
+// GetTestHistory returns the most recent occurrences of a test across
+// builds, newest first, so that its results can be compared over time
+func (t *TCClient) GetTestHistory(ctx context.Context, testName string, params TCTestHistoryParams) ([]TCTestOccurrence, error) {
+	locator := []string{fmt.Sprintf("test:(name:%s)", locatorValue(testName))}
+
+	if params.BuildTypeID != "" {
+		locator = append(locator, fmt.Sprintf("buildType:(id:%s)", params.BuildTypeID))
+	}
+
+	return t.listTestOccurrences(ctx, locator, params.Count)
+}
+
+// listTestOccurrences follows the pages of test occurrences matching
+// the locator until count occurrences are read, or all of them if
+// count is 0
+func (t *TCClient) listTestOccurrences(ctx context.Context, locator []string, count uint) ([]TCTestOccurrence, error) {
+	if count > 0 {
+		locator = append(locator, fmt.Sprintf("count:%d", count))
+	}
+
+	path := fmt.Sprintf(
+		"/app/rest/testOccurrences?locator=%s&fields=%s",
+		url.QueryEscape(strings.Join(locator, ",")),
+		testOccurrenceFields)
+
+	occurrences := []TCTestOccurrence{}
+	for path != "" {
+		var page TCTestOccurrences
+		if err := t.doRequest(ctx, "GET", path, nil, &page); err != nil {
+			return nil, err
+		}
+		occurrences = append(occurrences, page.TestOccurrence...)
+
+		if count > 0 && uint(len(occurrences)) >= count {
+			return occurrences[:count], nil
+		}
+		path = page.NextHref
+	}
+
+	return occurrences, nil
+}
+
+// locatorValue encodes a value so that it can be used in a locator
+// even when it contains characters such as ',', ':' or '('
+func locatorValue(value string) string {
+	return fmt.Sprintf("$base64:%s", base64.URLEncoding.EncodeToString([]byte(value)))
+}
@@ -0,0 +1,39 @@
+package teamcity
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultPollInterval is the interval at which the state of a build is
+// polled while waiting for it when no interval is provided
+const DefaultPollInterval = 10 * time.Second
+
+// WaitForBuild polls a queued or running build every pollInterval until
+// it finishes and returns its final details. It returns early with the
+// context error when ctx is done
+func (t *TCClient) WaitForBuild(ctx context.Context, id int, pollInterval time.Duration) (TCBuildDetails, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		buildDetails, err := t.GetBuildDetails(ctx, id)
+		if err != nil {
+			return buildDetails, err
+		}
+
+		if buildDetails.State == "finished" {
+			return buildDetails, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return buildDetails, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}